@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func expectAttrib(t *testing.T, h *fwatchtest.Harness, name string, want fwatch.AttrChange) {
+	t.Helper()
+
+	events := h.Events()
+	if len(events) != 1 || events[0].Event != fwatch.Attrib || events[0].Name != name {
+		t.Fatalf("expected Attrib of %s, got %v", name, events)
+	}
+
+	if got := *events[0].Attr; got != want {
+		t.Errorf("expected attr change %+v, got %+v", want, got)
+	}
+}
+
+func TestAttribEvents(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs")
+		h.Watch("/logs", false, func(string) bool { return true })
+		h.Advance(time.Second / 2)
+
+		h.WriteFile("/logs/a.log", []byte("a"))
+		h.Advance(3 * time.Second)
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.Create, "/logs/a.log"),
+			fwatchtest.Event(fwatch.Inactive, "/logs/a.log"),
+		)
+
+		// the ctime change of an attributes change doesn't make the file active.
+		h.Chmod("/logs/a.log", 0o666)
+		h.Advance(time.Second)
+		expectAttrib(t, h, "/logs/a.log", fwatch.AttrChange{
+			Old: fwatch.FileAttr{Mode: 0o644},
+			New: fwatch.FileAttr{Mode: 0o666},
+		})
+
+		h.Chown("/logs/a.log", 1000, 100)
+		h.Advance(time.Second)
+		expectAttrib(t, h, "/logs/a.log", fwatch.AttrChange{
+			Old: fwatch.FileAttr{Mode: 0o666},
+			New: fwatch.FileAttr{Mode: 0o666, UID: 1000, GID: 100},
+		})
+	}, fwatch.WithAttribEvents(true), fwatch.WithFingerprint(true), fwatch.WithSilenceDuration(10*time.Second))
+}
+
+func TestAttribEventsOS(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+
+	w, err := fwatch.New(
+		fwatch.WithMethod(fwatch.WatchMethodFS),
+		fwatch.WithInactiveDuration(10*time.Second),
+		fwatch.WithSilenceDuration(20*time.Second),
+		fwatch.WithAttribEvents(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	if err = w.WatchDir(tempDir, false, func(string) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+
+	filePath := filepath.Join(tempDir, "a.log")
+	_ = os.WriteFile(filePath, []byte("a"), filePerm)
+	waitEvent(t, w, filePath, fwatch.Create)
+
+	_ = os.Chmod(filePath, 0o644)
+	waitEvent(t, w, filePath, fwatch.Attrib)
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+)
+
+// ErrEventOverflow is reported by a backend when change notifications have been dropped,
+// the watcher rescans all directories when receiving it.
+var ErrEventOverflow = errors.New("backend event overflow")
+
+// Op describes a set of backend operations on a path.
+type Op uint32
+
+// These are the operations a backend can report.
+const (
+	OpCreate Op = 1 << iota
+	OpWrite
+	OpRemove
+	OpRename
+	OpChmod
+	OpCloseWrite
+)
+
+var opNames = []struct {
+	op   Op
+	name string
+}{
+	{OpCreate, "CREATE"},
+	{OpWrite, "WRITE"},
+	{OpRemove, "REMOVE"},
+	{OpRename, "RENAME"},
+	{OpChmod, "CHMOD"},
+	{OpCloseWrite, "CLOSE_WRITE"},
+}
+
+// Has whether the op set contains o.
+func (op Op) Has(o Op) bool {
+	return op&o == o
+}
+
+// String op desc, e.g. CREATE|WRITE.
+func (op Op) String() string {
+	var names []string
+
+	for _, n := range opNames {
+		if op.Has(n.op) {
+			names = append(names, n.name)
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
+// BackendEvent a change notification of a path reported by a backend.
+type BackendEvent struct {
+	Name string
+	Op   Op
+}
+
+// BackendSink receives the notifications of a backend.
+type BackendSink interface {
+	// HandleEvent handles a change of a path under a watched directory.
+	HandleEvent(event BackendEvent)
+
+	// HandleError handles a backend error, ErrEventOverflow triggers a rescan.
+	HandleError(err error)
+
+	// Logger returns the logger of the watcher, for the backend to write diagnostics to.
+	Logger() *slog.Logger
+}
+
+// Backend a source of directory change notifications of a file watcher.
+type Backend interface {
+	// Polling whether the backend doesn't notify changes,
+	// the watcher scans all directories on every tick for a polling backend.
+	Polling() bool
+
+	// Start starts delivering notifications of added directories to sink, until closed.
+	Start(sink BackendSink) error
+
+	// Add starts watching a directory.
+	Add(dir string) error
+
+	// Remove stops watching a directory.
+	Remove(dir string) error
+
+	// Close stops the backend and releases its resources.
+	Close() error
+}
+
+// backendSink delivers backend notifications to the file watcher.
+type backendSink struct {
+	fw *FileWatcher
+}
+
+func (s backendSink) HandleEvent(event BackendEvent) {
+	s.fw.handleBackendEvent(event)
+}
+
+func (s backendSink) HandleError(err error) {
+	s.fw.handleBackendError(err)
+}
+
+func (s backendSink) Logger() *slog.Logger {
+	return s.fw.logger
+}
@@ -0,0 +1,179 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fsBackend a backend using fsnotify to receive os file system notifications.
+type fsBackend struct {
+	closeWrite bool
+	done       chan struct{}
+	closeOnce  sync.Once
+	logger     *slog.Logger
+
+	watcher           *fsnotify.Watcher
+	closeWriteWatcher *closeWriteWatcher
+}
+
+// NewFSBackend creates the backend of the fs watch method.
+// CloseWrite notifications are reported if closeWrite is true and the platform supports it.
+// The backend logs to the logger of the watcher it's started by.
+func NewFSBackend(closeWrite bool) Backend {
+	return &fsBackend{
+		closeWrite: closeWrite,
+		done:       make(chan struct{}),
+	}
+}
+
+func (b *fsBackend) Polling() bool {
+	return false
+}
+
+func (b *fsBackend) Start(sink BackendSink) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	b.watcher = watcher
+	b.logger = sink.Logger()
+
+	if b.closeWrite {
+		b.closeWriteWatcher, err = newCloseWriteWatcher()
+		if err != nil {
+			b.logger.Warn("close write event disabled", "err", err)
+		} else {
+			go b.closeWriteWatcher.run(func(path string) {
+				sink.HandleEvent(BackendEvent{Name: path, Op: OpCloseWrite})
+			}, func() {
+				sink.HandleError(ErrEventOverflow)
+			}, b.logger)
+		}
+	}
+
+	go b.run(sink)
+
+	return nil
+}
+
+func (b *fsBackend) Add(dir string) error {
+	if err := b.watcher.Add(dir); err != nil {
+		return err
+	}
+
+	if b.closeWriteWatcher != nil {
+		return b.closeWriteWatcher.Add(dir)
+	}
+
+	return nil
+}
+
+func (b *fsBackend) Remove(dir string) error {
+	err := b.watcher.Remove(dir)
+
+	if b.closeWriteWatcher != nil {
+		err = errors.Join(err, b.closeWriteWatcher.Remove(dir))
+	}
+
+	return err
+}
+
+// Close stops the backend, it can be called more than once.
+func (b *fsBackend) Close() error {
+	if b.watcher == nil {
+		return nil
+	}
+
+	var err error
+
+	b.closeOnce.Do(func() {
+		close(b.done)
+
+		err = b.watcher.Close()
+
+		if b.closeWriteWatcher != nil {
+			err = errors.Join(err, b.closeWriteWatcher.Close())
+		}
+	})
+
+	return err
+}
+
+func (b *fsBackend) run(sink BackendSink) {
+	for {
+		select {
+		case <-b.done:
+			return
+		case event, ok := <-b.watcher.Events:
+			if !ok {
+				b.logger.Warn("failed to listen watch event")
+
+				return
+			}
+
+			sink.HandleEvent(BackendEvent{
+				Name: event.Name,
+				Op:   fsOp(event.Op),
+			})
+		case err, ok := <-b.watcher.Errors:
+			if !ok {
+				b.logger.Warn("failed to listen error event")
+
+				return
+			}
+
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				err = ErrEventOverflow
+			}
+
+			sink.HandleError(err)
+		}
+	}
+}
+
+func fsOp(op fsnotify.Op) Op {
+	var o Op
+
+	if op.Has(fsnotify.Create) {
+		o |= OpCreate
+	}
+
+	if op.Has(fsnotify.Write) {
+		o |= OpWrite
+	}
+
+	if op.Has(fsnotify.Remove) {
+		o |= OpRemove
+	}
+
+	if op.Has(fsnotify.Rename) {
+		o |= OpRename
+	}
+
+	if op.Has(fsnotify.Chmod) {
+		o |= OpChmod
+	}
+
+	return o
+}
@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+// manualBackend a backend whose notifications are sent by the test.
+type manualBackend struct {
+	mu   sync.Mutex
+	sink fwatch.BackendSink
+	dirs map[string]bool
+}
+
+func (b *manualBackend) Polling() bool { return false }
+
+func (b *manualBackend) Start(sink fwatch.BackendSink) error {
+	b.sink = sink
+	b.dirs = make(map[string]bool)
+
+	return nil
+}
+
+func (b *manualBackend) Add(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.dirs[dir] = true
+
+	return nil
+}
+
+func (b *manualBackend) Remove(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.dirs, dir)
+
+	return nil
+}
+
+func (b *manualBackend) Close() error { return nil }
+
+func (b *manualBackend) watching(dir string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.dirs[dir]
+}
+
+func waitEvent(t *testing.T, w *fwatch.FileWatcher, name string, event fwatch.Event) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case ev := <-w.Events:
+			t.Logf("[event] %s | %v", ev.Name, ev.Event)
+
+			if ev.Name == name && ev.Event == event {
+				return
+			}
+		case watchErr := <-w.Errors:
+			t.Logf("[error] %v", watchErr)
+		case <-timeout:
+			t.Fatalf("timed out waiting for %v of %s", event, name)
+		}
+	}
+}
+
+func TestCustomBackend(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	backend := &manualBackend{}
+
+	w, err := fwatch.New(
+		fwatch.WithBackend(backend),
+		fwatch.WithInactiveDuration(10*time.Second),
+		fwatch.WithSilenceDuration(20*time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	if err = w.WatchDir(tempDir, false, func(string) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+
+	if !backend.watching(tempDir) {
+		t.Fatalf("expected backend watching %s", tempDir)
+	}
+
+	// a notified file is watched.
+	notified := filepath.Join(tempDir, "notified.txt")
+	_ = os.WriteFile(notified, []byte("x"), filePerm)
+
+	go backend.sink.HandleEvent(fwatch.BackendEvent{Name: notified, Op: fwatch.OpCreate})
+
+	waitEvent(t, w, notified, fwatch.Create)
+
+	// a missed file is found by the overflow rescan.
+	missed := filepath.Join(tempDir, "missed.txt")
+	_ = os.WriteFile(missed, []byte("x"), filePerm)
+
+	go backend.sink.HandleError(fwatch.ErrEventOverflow)
+
+	waitEvent(t, w, tempDir, fwatch.Overflow)
+	waitEvent(t, w, missed, fwatch.Create)
+
+	if fixes := w.Stats().ReconcileFixes; fixes != 1 {
+		t.Errorf("expected 1 reconcile fix, got %d", fixes)
+	}
+}
+
+func TestOpString(t *testing.T) {
+	t.Parallel()
+
+	if got := (fwatch.OpCreate | fwatch.OpWrite).String(); got != "CREATE|WRITE" {
+		t.Errorf("Op.String() = %q, want %q", got, "CREATE|WRITE")
+	}
+}
+
+func TestStopTwice(t *testing.T) {
+	t.Parallel()
+
+	for _, method := range []fwatch.WatchMethod{fwatch.WatchMethodFS, fwatch.WatchMethodHybrid, fwatch.WatchMethodTimer} {
+		w, err := fwatch.New(fwatch.WithMethod(method))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = w.Stop(); err != nil {
+			t.Errorf("stop %s: %v", method, err)
+		}
+
+		if err = w.Stop(); err != nil {
+			t.Errorf("stop %s again: %v", method, err)
+		}
+	}
+}
+
+func TestHybridReconcile(t *testing.T) {
+	t.Parallel()
+
+	// the backend drops all notifications, the changes are found by the reconcile only.
+	h := fwatchtest.New(t, fwatch.WatchMethodHybrid,
+		fwatch.WithBackend(&manualBackend{}),
+		fwatch.WithReconcileInterval(5*time.Second),
+		fwatch.WithSilenceDuration(time.Hour),
+	)
+
+	h.MkdirAll("/logs")
+	h.Watch("/logs", true, func(string) bool { return true })
+	h.Advance(time.Second + time.Second/2)
+
+	h.WriteFile("/logs/a.log", []byte("a"))
+	h.MkdirAll("/logs/sub")
+	h.Advance(4 * time.Second)
+	h.ExpectNoEvents()
+
+	h.Advance(time.Second)
+	h.ExpectEvents(
+		fwatchtest.Event(fwatch.Create, "/logs/a.log"),
+		fwatchtest.Event(fwatch.DirCreate, "/logs/sub"),
+	)
+
+	if fixes := h.Watcher.Stats().ReconcileFixes; fixes != 2 {
+		t.Errorf("expected 2 reconcile fixes, got %d", fixes)
+	}
+}
+
+// failingBackend a manual backend failing to watch the directories named fail.
+type failingBackend struct {
+	manualBackend
+
+	fail string
+}
+
+func (b *failingBackend) Add(dir string) error {
+	if filepath.Base(dir) == b.fail {
+		return os.ErrPermission
+	}
+
+	return b.manualBackend.Add(dir)
+}
+
+func TestWatchesFailedAdd(t *testing.T) {
+	t.Parallel()
+
+	h := fwatchtest.New(t, fwatch.WatchMethodFS, fwatch.WithBackend(&failingBackend{fail: "denied"}))
+
+	h.MkdirAll("/logs/ok")
+	h.MkdirAll("/logs/denied")
+	h.Watch("/logs", true, func(string) bool { return true })
+	h.Advance(time.Second)
+
+	if stats := h.Watcher.Stats(); stats.Dirs != 3 || stats.Watches != 2 {
+		t.Errorf("want 3 dirs and 2 watches, got %d and %d", stats.Dirs, stats.Watches)
+	}
+}
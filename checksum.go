@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+)
+
+// checksumFile returns a hash of the content of a file. The whole content is hashed if size is 0,
+// otherwise only the first and last size bytes together with the file size.
+func checksumFile(fsys FS, path string, size int64) (uint64, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	hash := fnv.New64a()
+	fileSize := info.Size()
+
+	if size == 0 || fileSize <= size*2 {
+		if _, err = io.Copy(hash, file); err != nil {
+			return 0, err
+		}
+
+		return hash.Sum64(), nil
+	}
+
+	_ = binary.Write(hash, binary.LittleEndian, fileSize)
+
+	if _, err = io.CopyN(hash, file, size); err != nil {
+		return 0, err
+	}
+
+	tail := make([]byte, size)
+
+	if readerAt, ok := file.(io.ReaderAt); ok {
+		if _, err = readerAt.ReadAt(tail, fileSize-size); err != nil {
+			return 0, err
+		}
+	} else {
+		// skip the middle for files not supporting random access.
+		if _, err = io.CopyN(io.Discard, file, fileSize-size*2); err != nil {
+			return 0, err
+		}
+
+		if _, err = io.ReadFull(file, tail); err != nil {
+			return 0, err
+		}
+	}
+
+	_, _ = hash.Write(tail)
+
+	return hash.Sum64(), nil
+}
+
+// updateChecksum stores the content hash of a file into its stat,
+// and returns whether the content changed since the last stored hash.
+// Special files are never hashed, reading a FIFO blocks until a writer opens it.
+func (fw *FileWatcher) updateChecksum(path string, stat *FileStat) (changed bool, ok bool) {
+	if specialFileOf(stat.attr.Mode) != 0 {
+		return false, false
+	}
+
+	sum, err := checksumFile(fw.fs, path, fw.checksumSize)
+	if err != nil {
+		fw.logger.Debug("checksum file error", "root", stat.root, "path", path, "err", err)
+
+		return false, false
+	}
+
+	// no hash to compare with, e.g. failed when the file became inactive.
+	ok = stat.hasChecksum
+	changed = sum != stat.checksum
+
+	stat.checksum = sum
+	stat.hasChecksum = true
+
+	return changed, ok
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"fmt"
+	"io/fs"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []int64{0, 2} {
+		t.Run(fmt.Sprintf("size=%d", size), func(t *testing.T) {
+			fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+				h.MkdirAll("/logs")
+				h.Watch("/logs", false, func(string) bool { return true })
+				h.Advance(time.Second / 2)
+
+				h.WriteFile("/logs/a.log", []byte("content"))
+				h.Advance(3 * time.Second)
+				h.ExpectEvents(
+					fwatchtest.Event(fwatch.Create, "/logs/a.log"),
+					fwatchtest.Event(fwatch.Inactive, "/logs/a.log"),
+				)
+
+				// touch without content change.
+				h.Chtimes("/logs/a.log", h.Clock.Now())
+				h.Advance(time.Second)
+				h.ExpectNoEvents()
+
+				// content change keeping the mod time.
+				info, _ := h.FS.Stat("/logs/a.log")
+				h.WriteFile("/logs/a.log", []byte("changed"))
+				h.Chtimes("/logs/a.log", info.ModTime())
+				h.Advance(time.Second)
+				h.ExpectEvents(fwatchtest.Event(fwatch.Active, "/logs/a.log"))
+			}, fwatch.WithChecksum(size))
+		})
+	}
+}
+
+// openCountFS counts the files opened for reading the content.
+type openCountFS struct {
+	*fwatch.MemFS
+
+	opens atomic.Int64
+}
+
+func (f *openCountFS) Open(name string) (fs.File, error) {
+	f.opens.Add(1)
+
+	return f.MemFS.Open(name)
+}
+
+func TestChecksumUnchanged(t *testing.T) {
+	t.Parallel()
+
+	clock := fwatch.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	memFS := fwatch.NewMemFS()
+	memFS.SetClock(clock)
+	fsys := &openCountFS{MemFS: memFS}
+
+	w, err := fwatch.New(
+		fwatch.WithFS(fsys),
+		fwatch.WithClock(clock),
+		fwatch.WithInactiveDuration(2*time.Second),
+		fwatch.WithSilenceDuration(time.Hour),
+		fwatch.WithChecksum(0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	go func() {
+		for {
+			select {
+			case <-w.Events:
+			case <-w.Done():
+				return
+			}
+		}
+	}()
+
+	_ = memFS.MkdirAll("/logs")
+	if err = w.WatchDir("/logs", false, func(string) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Second / 2)
+	_ = memFS.WriteFile("/logs/a.log", []byte("content"))
+	clock.Advance(4 * time.Second)
+
+	// hashed once when the file became inactive, not on every check.
+	opens := fsys.opens.Load()
+	clock.Advance(10 * time.Second)
+
+	if got := fsys.opens.Load(); opens != 1 || got != opens {
+		t.Errorf("unexpected hashes of an unchanged file: %d, then %d", opens, got)
+	}
+}
@@ -0,0 +1,197 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and tickers to a watcher.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTicker returns a ticker delivering the time every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks of a Clock.
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// tickAcker is implemented by tickers which wait until their ticks have been handled.
+type tickAcker interface {
+	ack()
+}
+
+// realClock the clock of the os.
+type realClock struct{}
+
+// RealClock returns the clock of the os, which is the default of a watcher.
+func RealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{Ticker: time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// FakeClock a clock for tests, whose time only moves forward by Advance.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFakeClock creates a fake clock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the fake clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTicker returns a ticker firing when the fake clock is advanced past its next tick.
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{
+		c:       make(chan time.Time),
+		handled: make(chan struct{}),
+		stopped: make(chan struct{}),
+		period:  d,
+		next:    c.now.Add(d),
+	}
+
+	c.tickers = append(c.tickers, t)
+
+	return t
+}
+
+// Advance moves the fake clock forward by d. Tickers fire in time order on the way,
+// and Advance returns after all ticks have been handled by the watcher.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+
+		t := c.nextTicker(target)
+		if t == nil {
+			c.now = target
+			c.mu.Unlock()
+
+			return
+		}
+
+		c.now = t.next
+		t.next = t.next.Add(t.period)
+		now := c.now
+
+		c.mu.Unlock()
+
+		// deliver outside the lock, the watcher reads the clock when handling the tick.
+		t.fire(now)
+	}
+}
+
+// nextTicker returns the running ticker with the earliest tick not after target.
+func (c *FakeClock) nextTicker(target time.Time) *fakeTicker {
+	running := c.tickers[:0]
+
+	for _, t := range c.tickers {
+		select {
+		case <-t.stopped:
+		default:
+			running = append(running, t)
+		}
+	}
+
+	c.tickers = running
+
+	sort.SliceStable(running, func(i, j int) bool {
+		return running[i].next.Before(running[j].next)
+	})
+
+	if len(running) == 0 || running[0].next.After(target) {
+		return nil
+	}
+
+	return running[0]
+}
+
+// fakeTicker a ticker of FakeClock, waiting for each tick to be handled.
+type fakeTicker struct {
+	c        chan time.Time
+	handled  chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+	period   time.Duration
+	next     time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopped)
+	})
+}
+
+func (t *fakeTicker) ack() {
+	select {
+	case t.handled <- struct{}{}:
+	case <-t.stopped:
+	}
+}
+
+func (t *fakeTicker) fire(now time.Time) {
+	select {
+	case t.c <- now:
+	case <-t.stopped:
+		return
+	}
+
+	select {
+	case <-t.handled:
+	case <-t.stopped:
+	}
+}
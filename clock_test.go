@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+)
+
+// drainEvents returns the events sent so far without waiting.
+func drainEvents(w *fwatch.FileWatcher) []string {
+	var events []string
+
+	for {
+		select {
+		case ev := <-w.Events:
+			events = append(events, ev.Event.String()+" "+ev.Name)
+		default:
+			return events
+		}
+	}
+}
+
+func TestFakeClockLifecycle(t *testing.T) {
+	t.Parallel()
+
+	clock := fwatch.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	m := fwatch.NewMemFS()
+	m.SetClock(clock)
+	_ = m.MkdirAll("/logs")
+
+	w, err := fwatch.New(
+		fwatch.WithFS(m),
+		fwatch.WithClock(clock),
+		fwatch.WithInactiveDuration(inactiveDuration),
+		fwatch.WithSilenceDuration(silenceDuration),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	if err = w.WatchDir("/logs", false, func(string) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+
+	// keep changes apart from the dir mod time set when watching.
+	clock.Advance(time.Second / 2)
+
+	steps := []struct {
+		name    string
+		action  func()
+		advance time.Duration
+		want    []string
+	}{
+		{"create", func() { _ = m.WriteFile("/logs/a.log", []byte("a")) }, time.Second, []string{"Create /logs/a.log"}},
+		{"inactive", nil, 2 * time.Second, []string{"Inactive /logs/a.log"}},
+		{"write", func() { _ = m.AppendFile("/logs/a.log", []byte("b")) }, time.Second, []string{"Active /logs/a.log"}},
+		{"silence", nil, 5 * time.Second, []string{"Inactive /logs/a.log", "Silence /logs/a.log"}},
+	}
+
+	for _, step := range steps {
+		if step.action != nil {
+			step.action()
+		}
+
+		clock.Advance(step.advance)
+
+		if got := drainEvents(w); !slices.Equal(got, step.want) {
+			t.Errorf("step %s: got events %v, want %v", step.name, got, step.want)
+		}
+	}
+}
@@ -20,6 +20,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
@@ -35,10 +36,16 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		explain(os.Args[2:])
+
+		return
+	}
+
 	var (
 		file            = flag.String("file", "", "watch a single file for changes")
 		dir             = flag.String("dir", "", "watch a directory for file changes")
-		method          = flag.String("method", "timer", "watch method: fs (OS-level fsnotify) or timer (polling)")
+		method          = flag.String("method", "timer", "watch method: fs (OS-level fsnotify), timer (polling) or hybrid (fsnotify with periodic rescan)")
 		logLevel        = flag.String("log_level", "", "log level: debug or info")
 		includeSub      = flag.Bool("include_sub", false, "include sub-directories when watching a directory")
 		fileSuffix      = flag.String("suffix", "", "only watch files with this suffix (e.g. .log)")
@@ -52,11 +59,14 @@ func main() {
 Usage:
   fwatch -file <path>                     Watch a single file
   fwatch -dir <path> [options]            Watch a directory
+  fwatch explain -dir <path> [options] <file>...
+                                          Explain why files are or aren't watched
 
 Examples:
   fwatch -file /var/log/app.log
   fwatch -dir /var/log -method fs -include_sub -suffix .log
   fwatch -dir /tmp -inactive_seconds 30 -silence_seconds 120
+  fwatch explain -dir /var/log -include_sub -suffix .log /var/log/app/app.log
 
 Options:
 `)
@@ -77,6 +87,7 @@ Options:
 
 	if strings.EqualFold(*logLevel, "DEBUG") {
 		vlog.SetLevel(vlog.LevelDebug)
+		slog.SetLogLoggerLevel(slog.LevelDebug)
 	}
 
 	if *file != "" {
@@ -160,3 +171,55 @@ func watchDir(dir, method string, includeSub bool, fileSuffix string, inactiveSe
 
 	select {}
 }
+
+func explain(args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+
+	var (
+		dir            = flags.String("dir", "", "the directory watched")
+		includeSub     = flags.Bool("include_sub", false, "include sub-directories when watching the directory")
+		fileSuffix     = flags.String("suffix", "", "only watch files with this suffix (e.g. .log)")
+		silenceSeconds = flags.Int64("silence_seconds", defaultSilenceSeconds, "seconds before a file is removed from watch")
+	)
+
+	_ = flags.Parse(args)
+
+	if *dir == "" || flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: fwatch explain -dir <path> [options] <file>...")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+
+	watcher, err := fwatch.New(
+		fwatch.WithSilenceDuration(time.Duration(*silenceSeconds) * time.Second),
+	)
+	if err != nil {
+		vlog.Fatal(err)
+	}
+
+	defer func() {
+		_ = watcher.Stop()
+	}()
+
+	// drain the events of the initial scan.
+	go func() {
+		for {
+			select {
+			case <-watcher.Done():
+				return
+			case <-watcher.Events:
+			case <-watcher.Errors:
+			}
+		}
+	}()
+
+	if dirErr := watcher.WatchDir(*dir, *includeSub, func(s string) bool {
+		return *fileSuffix == "" || strings.HasSuffix(s, *fileSuffix)
+	}); dirErr != nil {
+		vlog.Fatal(dirErr)
+	}
+
+	for _, path := range flags.Args() {
+		fmt.Println(watcher.Explain(path))
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestDirEvents(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		// existing directories are not notified as created.
+		h.MkdirAll("/data/old")
+		h.Watch("/data", true, func(string) bool { return true })
+		h.Advance(time.Second / 2)
+		h.ExpectNoEvents()
+
+		h.MkdirAll("/data/p1")
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.DirCreate, "/data/p1"))
+
+		h.MkdirAll("/data/p1/sub")
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.DirCreate, "/data/p1/sub"))
+
+		h.WriteFile("/data/p1/sub/a.log", []byte("a"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/data/p1/sub/a.log"))
+
+		// files under a removed directory are removed with it.
+		h.RemoveAll("/data/p1")
+		h.Advance(time.Second)
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.Remove, "/data/p1/sub/a.log"),
+			fwatchtest.Event(fwatch.DirRemove, "/data/p1/sub"),
+			fwatchtest.Event(fwatch.DirRemove, "/data/p1"),
+		)
+
+		h.Remove("/data/old")
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.DirRemove, "/data/old"))
+
+		if stats := h.Watcher.Stats(); stats.Dirs != 1 || stats.Files != 0 {
+			t.Fatalf("unexpected stats after removing directories: %+v", stats)
+		}
+	},
+		fwatch.WithSilenceDuration(10*time.Second),
+	)
+}
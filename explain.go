@@ -0,0 +1,326 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Reason why a path is or isn't watched.
+type Reason uint8
+
+// These are the reasons reported by Explain.
+const (
+	// ReasonWatched the file is watched.
+	ReasonWatched Reason = iota
+
+	// ReasonPending the file is not watched yet, it will be detected by the next check of its directory.
+	ReasonPending
+
+	// ReasonNotExist the file does not exist.
+	ReasonNotExist
+
+	// ReasonStatError the file can't be stat.
+	ReasonStatError
+
+	// ReasonIsDir the path is a directory, only files are watched.
+	ReasonIsDir
+
+	// ReasonNotUnderRoot the file is not under any directory passed to WatchDir.
+	ReasonNotUnderRoot
+
+	// ReasonSubDirExcluded the file is in a sub directory of a root watched without sub directories.
+	ReasonSubDirExcluded
+
+	// ReasonDirTooManyFiles the directory of the file has more files than the dir file count limit.
+	ReasonDirTooManyFiles
+
+	// ReasonDirDropped the directory of the file is not watched any more for an error.
+	ReasonDirDropped
+
+	// ReasonNotMatched the file matcher rejected the file name.
+	ReasonNotMatched
+
+	// ReasonSilenced the file is not updated in the silence duration.
+	ReasonSilenced
+
+	// ReasonRootPending the root directory of the file does not exist, and is waiting to be created.
+	ReasonRootPending
+
+	// ReasonCrossDevice the directory of the file is on another device than its root, e.g. a mount point.
+	ReasonCrossDevice
+
+	// ReasonSpecialFile the file is a special file, e.g. a FIFO or a socket, not included by WithSpecialFiles.
+	ReasonSpecialFile
+
+	// ReasonDirUnwatched the directory of the file was passed to UnwatchDir.
+	ReasonDirUnwatched
+)
+
+// String reason desc.
+func (r Reason) String() string {
+	switch r {
+	case ReasonWatched:
+		return "watched"
+	case ReasonPending:
+		return "pending"
+	case ReasonNotExist:
+		return "not exist"
+	case ReasonStatError:
+		return "stat error"
+	case ReasonIsDir:
+		return "is dir"
+	case ReasonNotUnderRoot:
+		return "not under root"
+	case ReasonSubDirExcluded:
+		return "sub dir excluded"
+	case ReasonDirTooManyFiles:
+		return "dir too many files"
+	case ReasonDirDropped:
+		return "dir dropped"
+	case ReasonNotMatched:
+		return "not matched"
+	case ReasonSilenced:
+		return "silenced"
+	case ReasonRootPending:
+		return "root pending"
+	case ReasonCrossDevice:
+		return "cross device"
+	case ReasonSpecialFile:
+		return "special file"
+	case ReasonDirUnwatched:
+		return "dir unwatched"
+	}
+
+	return ""
+}
+
+// Explanation the verdict of Explain.
+type Explanation struct {
+	// Path the explained path, with symlinks resolved if it exists.
+	Path    string
+	Watched bool
+	Reason  Reason
+
+	// State the lifecycle state of the file.
+	State State
+
+	// Dir the directory which the verdict is decided by, e.g. the dropped or not included one.
+	Dir string
+
+	// Root the directory passed to WatchDir which the path is under, empty if none.
+	Root string
+
+	// Err the error of the stat or the dropped directory.
+	Err error
+}
+
+// String explanation desc, e.g. `/logs/a.txt: not matched, dir /logs, root /logs`.
+func (e Explanation) String() string {
+	desc := fmt.Sprintf("%s: %s", e.Path, e.Reason)
+
+	if e.Watched {
+		desc += ", " + e.State.String()
+	}
+
+	if e.Dir != "" {
+		desc += ", dir " + e.Dir
+	}
+
+	if e.Root != "" {
+		desc += ", root " + e.Root
+	}
+
+	if e.Err != nil {
+		desc += fmt.Sprintf(", error: %v", e.Err)
+	}
+
+	return desc
+}
+
+// Explain reports why a file is or isn't watched, walking the same decisions
+// as scanning its directory.
+func (fw *FileWatcher) Explain(path string) Explanation {
+	path = filepath.Clean(path)
+	realPath, isDir, info, statErr := fw.explainStat(path)
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for _, p := range []string{path, realPath} {
+		if stat, ok := fw.watchedFile(p); ok {
+			snapshot := fileSnapshot(p, stat, false)
+
+			return Explanation{
+				Path:    p,
+				Watched: true,
+				Reason:  ReasonWatched,
+				State:   snapshot.State,
+				Dir:     filepath.Dir(p),
+				Root:    stat.root,
+			}
+		}
+	}
+
+	e := Explanation{Path: path, State: StateUntracked}
+
+	switch {
+	case os.IsNotExist(statErr):
+		e.Reason = ReasonNotExist
+		fw.explainPendingRoot(&e)
+	case statErr != nil:
+		e.Reason = ReasonStatError
+		e.Err = statErr
+	case isDir:
+		e.Path = realPath
+		e.Reason = ReasonIsDir
+		e.Root = fw.rootOf(realPath)
+	default:
+		e.Path = realPath
+		fw.explainFile(&e, info)
+	}
+
+	return e
+}
+
+func (fw *FileWatcher) explainStat(path string) (string, bool, os.FileInfo, error) {
+	info, err := fw.fs.Lstat(path)
+	if err != nil {
+		return path, false, nil, err
+	}
+
+	return unlink(fw.fs, path, info)
+}
+
+// explainFile decides the verdict of an existing file not watched.
+func (fw *FileWatcher) explainFile(e *Explanation, info os.FileInfo) {
+	e.Dir = filepath.Dir(e.Path)
+
+	dirStat, ok := fw.watchedDir(e.Dir)
+	if !ok {
+		fw.explainDir(e)
+
+		return
+	}
+
+	e.Root = dirStat.root
+
+	switch {
+	case specialFileOf(info.Mode())&^fw.specialFiles != 0:
+		e.Reason = ReasonSpecialFile
+	case !dirStat.matcher(info.Name()):
+		e.Reason = ReasonNotMatched
+	case !info.ModTime().After(fw.skewDeadline(dirStat.root, fw.clock.Now().Add(-fw.silenceDuration))):
+		e.Reason = ReasonSilenced
+	default:
+		e.Reason = ReasonPending
+	}
+}
+
+// explainDir decides the verdict of a file whose directory is not watched,
+// by the nearest watched or dropped ancestor directory.
+func (fw *FileWatcher) explainDir(e *Explanation) {
+	for dir := e.Dir; ; dir = filepath.Dir(dir) {
+		if root, ok := fw.skippedMounts[dir]; ok {
+			e.Dir = dir
+			e.Root = root
+			e.Reason = ReasonCrossDevice
+
+			return
+		}
+
+		if root, ok := fw.unwatchedDirs[dir]; ok {
+			e.Dir = dir
+			e.Root = root
+			e.Reason = ReasonDirUnwatched
+
+			return
+		}
+
+		if dropped, ok := fw.droppedDirs[dir]; ok {
+			e.Dir = dir
+			e.Err = dropped.err
+			e.Reason = ReasonDirDropped
+
+			if errors.Is(dropped.err, ErrTooManyDirFile) {
+				e.Reason = ReasonDirTooManyFiles
+			}
+
+			return
+		}
+
+		if dirStat, ok := fw.watchedDir(dir); ok {
+			e.Root = dirStat.root
+
+			if dirStat.includeSub {
+				e.Reason = ReasonPending
+			} else {
+				e.Dir = dir
+				e.Reason = ReasonSubDirExcluded
+			}
+
+			return
+		}
+
+		if parent := filepath.Dir(dir); parent == dir {
+			e.Reason = ReasonNotUnderRoot
+
+			return
+		}
+	}
+}
+
+// explainPendingRoot decides the verdict of a file not existing for its root removed.
+func (fw *FileWatcher) explainPendingRoot(e *Explanation) {
+	for dir := filepath.Dir(e.Path); ; dir = filepath.Dir(dir) {
+		if _, ok := fw.pendingRoots[dir]; ok {
+			e.Dir = dir
+			e.Root = dir
+			e.Reason = ReasonRootPending
+
+			return
+		}
+
+		if parent := filepath.Dir(dir); parent == dir {
+			return
+		}
+	}
+}
+
+// watchedDir returns the stat of a watched or newly added directory.
+func (fw *FileWatcher) watchedDir(dir string) (*DirStat, bool) {
+	if stat, ok := fw.dirs[dir]; ok {
+		return stat, true
+	}
+
+	stat, ok := fw.newDirs[dir]
+
+	return stat, ok
+}
+
+// rootOf returns the root of a watched directory, empty if not watched.
+func (fw *FileWatcher) rootOf(dir string) string {
+	if stat, ok := fw.watchedDir(dir); ok {
+		return stat.root
+	}
+
+	return ""
+}
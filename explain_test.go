@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	h := fwatchtest.New(t, fwatch.WatchMethodTimer)
+
+	h.MkdirAll("/logs/sub")
+	h.MkdirAll("/big/many")
+	h.MkdirAll("/other")
+	h.WriteFile("/logs/a.log", []byte("a"))
+	h.WriteFile("/logs/a.txt", []byte("a"))
+	h.WriteFile("/logs/old.log", []byte("old"))
+	h.Chtimes("/logs/old.log", h.Clock.Now().Add(-time.Hour))
+	h.WriteFile("/logs/sub/b.log", []byte("b"))
+	h.WriteFile("/other/c.log", []byte("c"))
+
+	for i := range 129 {
+		h.WriteFile(fmt.Sprintf("/big/many/%d.log", i), []byte("x"))
+	}
+
+	isLog := func(name string) bool { return strings.HasSuffix(name, ".log") }
+	h.Watch("/logs", false, isLog)
+	h.Watch("/big", true, isLog)
+
+	h.WriteFile("/logs/new.log", []byte("new"))
+
+	tests := []struct {
+		path   string
+		reason fwatch.Reason
+		dir    string
+		root   string
+	}{
+		{"/logs/a.log", fwatch.ReasonWatched, "/logs", "/logs"},
+		{"/logs/new.log", fwatch.ReasonPending, "/logs", "/logs"},
+		{"/logs/none.log", fwatch.ReasonNotExist, "", ""},
+		{"/logs/sub", fwatch.ReasonIsDir, "", ""},
+		{"/logs/a.txt", fwatch.ReasonNotMatched, "/logs", "/logs"},
+		{"/logs/old.log", fwatch.ReasonSilenced, "/logs", "/logs"},
+		{"/logs/sub/b.log", fwatch.ReasonSubDirExcluded, "/logs", "/logs"},
+		{"/other/c.log", fwatch.ReasonNotUnderRoot, "/other", ""},
+		{"/big/many/1.log", fwatch.ReasonDirTooManyFiles, "/big/many", ""},
+	}
+
+	for _, tt := range tests {
+		e := h.Watcher.Explain(tt.path)
+		if e.Reason != tt.reason || e.Dir != tt.dir || e.Root != tt.root || e.Watched != (tt.reason == fwatch.ReasonWatched) {
+			t.Errorf("Explain(%s) = %s, want reason %s, dir %q, root %q", tt.path, e, tt.reason, tt.dir, tt.root)
+		}
+	}
+
+	if e := h.Watcher.Explain("/big/many/1.log"); !errors.Is(e.Err, fwatch.ErrTooManyDirFile) {
+		t.Errorf("expected too many files error, got %v", e.Err)
+	}
+
+	if e := h.Watcher.Explain("/logs/a.log"); e.String() != "/logs/a.log: watched, Active, dir /logs, root /logs" {
+		t.Errorf("unexpected explanation: %s", e)
+	}
+}
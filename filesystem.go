@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// maxSymlinkHops the max count of symbolic links to follow when resolving a path.
+const maxSymlinkHops = 255
+
+// FS the file system which a watcher scans directories and files in.
+type FS interface {
+	// Stat returns the file info of name, following symbolic links.
+	Stat(name string) (os.FileInfo, error)
+
+	// Lstat returns the file info of name, not following symbolic links.
+	Lstat(name string) (os.FileInfo, error)
+
+	// ReadDir returns the entries of directory name sorted by file name.
+	ReadDir(name string) ([]os.DirEntry, error)
+
+	// EvalSymlinks returns the path name after resolving all symbolic links.
+	EvalSymlinks(name string) (string, error)
+
+	// Open opens a file for reading its content.
+	Open(name string) (fs.File, error)
+}
+
+// WritableFS a file system supporting to write files, which the clock skew probe requires.
+type WritableFS interface {
+	FS
+
+	// WriteFile writes data to a file, creating it if not exists.
+	WriteFile(name string, data []byte) error
+
+	// Remove removes a file.
+	Remove(name string) error
+}
+
+// osFS the file system of the os.
+type osFS struct{}
+
+// OSFS returns the file system of the os, which is the default of a watcher.
+func OSFS() FS {
+	return osFS{}
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
+
+func (osFS) Lstat(name string) (os.FileInfo, error) { return os.Lstat(name) }
+
+func (osFS) ReadDir(name string) ([]os.DirEntry, error) { return os.ReadDir(name) }
+
+func (osFS) EvalSymlinks(name string) (string, error) { return filepath.EvalSymlinks(name) }
+
+func (osFS) Open(name string) (fs.File, error) { return os.Open(name) }
+
+func (osFS) WriteFile(name string, data []byte) error { return os.WriteFile(name, data, probeFilePerm) }
+
+func (osFS) Remove(name string) error { return os.Remove(name) }
+
+// ioFS adapts an io/fs file system.
+type ioFS struct {
+	fsys fs.FS
+}
+
+// IOFS adapts an io/fs file system, e.g. os.DirFS or fstest.MapFS, to a watcher file system.
+// Names passed to the watcher must be valid io/fs paths, e.g. "logs/app".
+// Symbolic links are followed only if fsys implements fs.ReadLinkFS.
+func IOFS(fsys fs.FS) FS {
+	return ioFS{fsys: fsys}
+}
+
+func (f ioFS) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(f.fsys, name)
+}
+
+func (f ioFS) Lstat(name string) (os.FileInfo, error) {
+	return fs.Lstat(f.fsys, name)
+}
+
+func (f ioFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return fs.ReadDir(f.fsys, name)
+}
+
+func (f ioFS) Open(name string) (fs.File, error) {
+	return f.fsys.Open(name)
+}
+
+func (f ioFS) EvalSymlinks(name string) (string, error) {
+	linkFS, ok := f.fsys.(fs.ReadLinkFS)
+	if !ok {
+		return name, nil
+	}
+
+	for range maxSymlinkHops {
+		info, err := linkFS.Lstat(name)
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return name, nil
+		}
+
+		target, err := linkFS.ReadLink(name)
+		if err != nil {
+			return "", err
+		}
+
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(name), target)
+		}
+
+		name = target
+	}
+
+	return "", &fs.PathError{Op: "evalsymlinks", Path: name, Err: errTooManyLinks}
+}
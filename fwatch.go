@@ -20,11 +20,12 @@ package fwatch
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
-	"github.com/vogo/vogo/vlog"
 	"github.com/vogo/vogo/vsync/vrun"
 )
 
@@ -32,6 +33,7 @@ const (
 	defaultMapSize           = 32
 	minimalInactiveDeadline  = time.Second
 	defaultDirFileCountLimit = 128
+	defaultReconcileInterval = time.Minute
 )
 
 type WatchMethod string
@@ -42,6 +44,28 @@ const (
 
 	// WatchMethodTimer interval schedule check stat of files and trigger file change events.
 	WatchMethodTimer WatchMethod = "timer"
+
+	// WatchMethodHybrid using os file system api to watch file events,
+	// and periodically rescan directories to fix events missed by the file system api.
+	WatchMethodHybrid WatchMethod = "hybrid"
+)
+
+// InitialScan how WatchDir notifies the files existing in a directory.
+type InitialScan uint8
+
+const (
+	// InitialScanIgnoreOld notifies Create for the existing files updated in the silence duration,
+	// older files are ignored.
+	InitialScanIgnoreOld InitialScan = iota
+
+	// InitialScanCreate notifies Create for all existing files.
+	// A file not updated in the inactive duration is watched as inactive, with no Inactive event,
+	// and a file not updated in the silence duration is notified only, not watched like a silenced file.
+	InitialScanCreate
+
+	// InitialScanExists notifies Exists for all existing files,
+	// the old files are watched or not like InitialScanCreate.
+	InitialScanExists
 )
 
 // FileMatcher whether a file name matches.
@@ -51,27 +75,88 @@ type FileMatcher func(string) bool
 type Event uint32
 
 // These are file events that can trigger a notification.
+// Create, Exists, Active, Inactive, Silence and Remove are lifecycle events, see State.
 const (
 	Create Event = 1 << iota
-	Write
+
+	// Active an inactive file is written again.
+	Active
 	Remove
 	Inactive
 	Silence
+	Overflow
+
+	// CloseWrite a file opened for writing was closed, only reported in fs and hybrid methods on linux.
+	CloseWrite
+
+	// Modify an active file was written, only reported if modify events enabled.
+	Modify
+
+	// Attrib the mode or owner of a file changed, only reported if attrib events enabled.
+	Attrib
+
+	// DirCreate a sub directory is created under a watched directory.
+	DirCreate
+
+	// DirRemove a watched directory is removed, after the Remove events of the files under it.
+	DirRemove
+
+	// Exists a file existed when its directory was watched, only reported in the InitialScanExists mode.
+	Exists
+
+	// Unwatched a file is not watched any more for its directory unwatched, only reported if unwatched events enabled.
+	Unwatched
+
+	// RootRestored a removed root directory is created again and watched, only reported if persistent roots enabled.
+	RootRestored
+
+	// DirResumed a directory dropped for an error is watched again, only reported if dir retry enabled.
+	DirResumed
+
+	// DirAbandoned a directory dropped for an error is not retried any more, only reported if dir retry enabled.
+	DirAbandoned
 )
 
+// Write is the former name of Active.
+//
+// Deprecated: use Active, Write is an alias of it. Writes to active files are notified by Modify.
+const Write = Active
+
 // String event desc.
 func (e Event) String() string {
 	switch e {
 	case Create:
 		return "Create"
-	case Write:
-		return "Write"
+	case Active:
+		return "Active"
 	case Remove:
 		return "Remove"
 	case Inactive:
 		return "Inactive"
 	case Silence:
 		return "Silence"
+	case Overflow:
+		return "Overflow"
+	case CloseWrite:
+		return "CloseWrite"
+	case Modify:
+		return "Modify"
+	case Attrib:
+		return "Attrib"
+	case DirCreate:
+		return "DirCreate"
+	case DirRemove:
+		return "DirRemove"
+	case Exists:
+		return "Exists"
+	case Unwatched:
+		return "Unwatched"
+	case RootRestored:
+		return "RootRestored"
+	case DirResumed:
+		return "DirResumed"
+	case DirAbandoned:
+		return "DirAbandoned"
 	}
 
 	return ""
@@ -81,12 +166,35 @@ func (e Event) String() string {
 type WatchEvent struct {
 	Name  string
 	Event Event
+
+	// Change the changed fields of an Active or Modify event.
+	Change Change
+
+	// Attr the old and new attributes of an Attrib event.
+	Attr *AttrChange
 }
 
 // FileStat file stat.
 type FileStat struct {
 	modTime time.Time
 	active  bool
+
+	// content hash of the file when it became inactive, if checksum enabled.
+	checksum    uint64
+	hasChecksum bool
+
+	// size, ctime and inode of the file at the last check, if fingerprint enabled.
+	fingerprint fingerprint
+
+	// the time of the last Modify event, and whether a write is waiting for the modify interval.
+	lastModify    time.Time
+	modifyPending bool
+
+	// mode and owner of the file at the last check.
+	attr FileAttr
+
+	// the root directory passed to WatchDir which this file belongs to.
+	root string
 }
 
 // DirStat dir stat.
@@ -94,6 +202,13 @@ type DirStat struct {
 	modTime    time.Time
 	includeSub bool
 	matcher    FileMatcher
+
+	// the root directory passed to WatchDir which this directory belongs to.
+	root string
+
+	// the device id of the root directory, if available on the platform.
+	dev    uint64
+	hasDev bool
 }
 
 // FileWatcher a file watcher, watch change event in directory/sub-directories.
@@ -104,7 +219,7 @@ type FileWatcher struct {
 	// runner to control watching goroutines.
 	runner *vrun.Runner
 
-	// watch method, fs or timer.
+	// watch method, fs, timer or hybrid.
 	method WatchMethod
 
 	// a duration if a file not being updated in, then it's inactive.
@@ -131,22 +246,109 @@ type FileWatcher struct {
 	// a channel to notify errors.
 	Errors chan error
 
-	// func to call for a new dir.
-	newDirWatchInit func(dir string)
+	// backend to receive directory change notifications.
+	backend Backend
+
+	// file system to scan directories and files in.
+	fs FS
+
+	// clock to get current time and tickers from.
+	clock Clock
+
+	// interval to rescan all directories in hybrid method.
+	reconcileInterval time.Duration
+
+	// next time to rescan all directories in hybrid method.
+	nextReconcile time.Time
+
+	// count of events missed by fsnotify and fixed by rescan.
+	reconcileFixes int64
+
+	// whether to notify CloseWrite events in fs and hybrid methods.
+	closeWrite bool
+
+	// whether to detect changes of inactive files by content hash instead of mod time.
+	checksum bool
 
-	// func to check dir.
-	timerDirsChecker func(silenceDeadline time.Time)
+	// bytes to hash at the head and tail of a file, 0 to hash the whole file.
+	checksumSize int64
+
+	// whether to detect changes of inactive files by size, ctime and inode besides mod time.
+	fingerprint bool
+
+	// whether to notify Modify events for writes to active files.
+	modifyEvents bool
+
+	// min interval between two Modify events of a file.
+	modifyInterval time.Duration
+
+	// whether to notify Attrib events for mode and owner changes of files.
+	attribEvents bool
 
 	// not watch file changes for a directory if the count of files under it is over the max.
 	dirFileCountLimit int
 
-	// closeFn is called on Stop to release resources (e.g. close fsnotify watcher).
-	closeFn func() error
+	// whether WatchDir is scanning the existing files and directories of a root.
+	initialScan bool
+
+	// how WatchDir notifies the existing files.
+	initialScanMode InitialScan
+
+	// directories not watched any more for an error, e.g. too many files.
+	droppedDirs map[string]*droppedDir
+
+	// policy to retry watching the dropped directories, nil to not retry.
+	dirRetry *RetryPolicy
+
+	// operational metrics.
+	metrics *metrics
+
+	// logger to write diagnostics to.
+	logger *slog.Logger
+
+	// whether to notify Unwatched events for the files under an unwatched directory.
+	unwatchedEvents bool
+
+	// whether to watch a removed root directory again once it's recreated.
+	persistentRoots bool
+
+	// root directories not existing, to watch once they're created.
+	pendingRoots map[string]*pendingRoot
+
+	// interval to measure the clock skews of root directories, 0 to not measure.
+	skewProbeInterval time.Duration
+
+	// next time to measure the clock skews.
+	nextSkewProbe time.Time
+
+	// clock skews of the file systems of root directories.
+	skews map[string]time.Duration
+
+	// whether to not watch sub directories on other devices than their root.
+	noCrossDevice bool
+
+	// sub directories not watched for on other devices, mapped to their root.
+	skippedMounts map[string]string
+
+	// types of special files to watch, e.g. FIFOs, which are skipped by default.
+	specialFiles SpecialFile
+
+	// sub directories passed to UnwatchDir, mapped to their root, not to watch again when their parent changes.
+	unwatchedDirs map[string]string
+
+	// directories added to the backend successfully.
+	watches map[string]struct{}
+
+	// ancestor directories watched by the backend for pending roots, with the count of roots using them.
+	anchors map[string]int
 }
 
 var (
 	errFileMatcherNil = errors.New("fileMatcher nil")
 
+	// ErrNotWatched is returned when unwatching a directory not watched.
+	ErrNotWatched = errors.New("dir not watched")
+
 	// ErrInvalidDirFileCountLimit is returned when the dir file count limit is out of range.
 	ErrInvalidDirFileCountLimit = errors.New("dirFileCountLimit must be between 32 and 1024")
 )
@@ -154,7 +356,7 @@ var (
 // Option configures a FileWatcher.
 type Option func(*FileWatcher) error
 
-// WithMethod sets the watch method (fs or timer). Default is timer.
+// WithMethod sets the watch method (fs, timer or hybrid). Default is timer.
 func WithMethod(method WatchMethod) Option {
 	return func(fw *FileWatcher) error {
 		fw.method = method
@@ -162,6 +364,32 @@ func WithMethod(method WatchMethod) Option {
 	}
 }
 
+// WithBackend sets a custom backend to receive directory change notifications,
+// instead of the one of the watch method.
+func WithBackend(backend Backend) Option {
+	return func(fw *FileWatcher) error {
+		fw.backend = backend
+		return nil
+	}
+}
+
+// WithFS sets the file system to scan directories and files in, default is the os file system.
+func WithFS(fsys FS) Option {
+	return func(fw *FileWatcher) error {
+		fw.fs = fsys
+		return nil
+	}
+}
+
+// WithClock sets the clock to get current time and tickers from, default is the os clock.
+// Use a FakeClock to test the inactive and silence lifecycle without sleeping.
+func WithClock(clock Clock) Option {
+	return func(fw *FileWatcher) error {
+		fw.clock = clock
+		return nil
+	}
+}
+
 // WithInactiveDuration sets the inactive duration threshold.
 func WithInactiveDuration(d time.Duration) Option {
 	return func(fw *FileWatcher) error {
@@ -183,6 +411,179 @@ func WithSilenceDuration(d time.Duration) Option {
 	}
 }
 
+// WithReconcileInterval sets the interval to rescan all directories in hybrid method.
+func WithReconcileInterval(d time.Duration) Option {
+	return func(fw *FileWatcher) error {
+		if d < minFsWatcherTimerInterval {
+			return fmt.Errorf("reconcileInterval %s is less than the minimal %s", d, minFsWatcherTimerInterval)
+		}
+
+		fw.reconcileInterval = d
+
+		return nil
+	}
+}
+
+// WithCloseWrite enables CloseWrite events in fs and hybrid methods.
+// It's only supported on linux, other platforms fall back to the Inactive event.
+func WithCloseWrite(enable bool) Option {
+	return func(fw *FileWatcher) error {
+		fw.closeWrite = enable
+		return nil
+	}
+}
+
+// WithChecksum detects changes of inactive files by content hash instead of mod time,
+// so Active is only sent when the content really changed, even if the mod time is unchanged or only touched.
+// The whole file is hashed if size is 0, otherwise only the first and last size bytes and the file size.
+func WithChecksum(size int64) Option {
+	return func(fw *FileWatcher) error {
+		if size < 0 {
+			return fmt.Errorf("checksum size %d is negative", size)
+		}
+
+		fw.checksum = true
+		fw.checksumSize = size
+
+		return nil
+	}
+}
+
+// WithFingerprint detects changes of inactive files by size, ctime and inode besides mod time,
+// to catch changes keeping the same mod time, e.g. `cp -p` or a file swapped by rename.
+// The changed fields are reported in the Change of the Active event.
+func WithFingerprint(enable bool) Option {
+	return func(fw *FileWatcher) error {
+		fw.fingerprint = enable
+		return nil
+	}
+}
+
+// WithModifyEvents enables Modify events for writes to active files, at most one per file in interval.
+// A write within the interval is notified after the interval if the file is still active.
+func WithModifyEvents(interval time.Duration) Option {
+	return func(fw *FileWatcher) error {
+		if interval < 0 {
+			return fmt.Errorf("modify interval %s is negative", interval)
+		}
+
+		fw.modifyEvents = true
+		fw.modifyInterval = interval
+
+		return nil
+	}
+}
+
+// WithAttribEvents enables Attrib events for mode and owner changes of watched files.
+func WithAttribEvents(enable bool) Option {
+	return func(fw *FileWatcher) error {
+		fw.attribEvents = enable
+		return nil
+	}
+}
+
+// WithInitialScan sets how WatchDir notifies the files existing in a directory,
+// default is InitialScanIgnoreOld.
+func WithInitialScan(mode InitialScan) Option {
+	return func(fw *FileWatcher) error {
+		if mode > InitialScanExists {
+			return fmt.Errorf("invalid initial scan mode %d", mode)
+		}
+
+		fw.initialScanMode = mode
+
+		return nil
+	}
+}
+
+// WithMetricsSink sets a sink to receive the operational metrics, e.g. NewExpvarMetrics.
+// The metrics are also collected in Stats without a sink.
+func WithMetricsSink(sink MetricsSink) Option {
+	return func(fw *FileWatcher) error {
+		fw.metrics.sink = sink
+		return nil
+	}
+}
+
+// WithUnwatchedEvents enables Unwatched events for the files under a directory passed to UnwatchDir.
+func WithUnwatchedEvents(enable bool) Option {
+	return func(fw *FileWatcher) error {
+		fw.unwatchedEvents = enable
+		return nil
+	}
+}
+
+// WithPersistentRoots keeps polling a removed root directory passed to WatchDir,
+// and watches it again with a RootRestored event once it's recreated.
+func WithPersistentRoots(enable bool) Option {
+	return func(fw *FileWatcher) error {
+		fw.persistentRoots = enable
+		return nil
+	}
+}
+
+// WithDirRetry retries watching a directory dropped for an error with backoff,
+// DirResumed is sent when it's watched again, and DirAbandoned when the max attempts reached.
+func WithDirRetry(policy RetryPolicy) Option {
+	return func(fw *FileWatcher) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+
+		fw.dirRetry = &policy
+
+		return nil
+	}
+}
+
+// WithSkewProbe measures the clock skew of the file system of each root directory in interval,
+// by writing a probe file named SkewProbeName in it, and shifts the deadlines of the files under it.
+// It tolerates file systems with a skewed clock, e.g. NFS or SMB, on which files may stay active
+// forever or be silenced immediately. The file system must implement WritableFS.
+func WithSkewProbe(interval time.Duration) Option {
+	return func(fw *FileWatcher) error {
+		if interval < minFsWatcherTimerInterval {
+			return fmt.Errorf("skew probe interval %s is less than the minimal %s", interval, minFsWatcherTimerInterval)
+		}
+
+		fw.skewProbeInterval = interval
+
+		return nil
+	}
+}
+
+// WithSpecialFiles watches the given types of special files, e.g. SpecialFIFO|SpecialSocket,
+// which are skipped by default. Special files are never hashed for WithChecksum, reading them may block.
+func WithSpecialFiles(types SpecialFile) Option {
+	return func(fw *FileWatcher) error {
+		fw.specialFiles = types
+		return nil
+	}
+}
+
+// WithNoCrossDevice stops watching sub directories at other devices than their root,
+// e.g. mount points or bind mounts, like `find -xdev`. The skipped directories are reported by Explain.
+func WithNoCrossDevice(enable bool) Option {
+	return func(fw *FileWatcher) error {
+		fw.noCrossDevice = enable
+		return nil
+	}
+}
+
+// WithLogger sets the logger to write diagnostics to, default is slog.Default().
+// The most verbose logs are written at LevelTrace.
+func WithLogger(logger *slog.Logger) Option {
+	return func(fw *FileWatcher) error {
+		if logger == nil {
+			return errors.New("logger nil")
+		}
+
+		fw.logger = logger
+
+		return nil
+	}
+}
+
 // WithDirFileCountLimit sets the max file count per directory.
 func WithDirFileCountLimit(count int) Option {
 	return func(fw *FileWatcher) error {
@@ -202,16 +603,26 @@ func New(opts ...Option) (*FileWatcher, error) {
 		mu:                sync.Mutex{},
 		runner:            vrun.New(),
 		method:            WatchMethodTimer,
+		fs:                OSFS(),
+		clock:             RealClock(),
 		inactiveDuration:  minimalInactiveDeadline,
 		silenceDuration:   minimalInactiveDeadline * 2,
 		dirs:              make(map[string]*DirStat, defaultMapSize),
 		files:             make(map[string]*FileStat, defaultMapSize),
 		newDirs:           make(map[string]*DirStat, defaultMapSize),
 		newFiles:          make(map[string]*FileStat, defaultMapSize),
+		droppedDirs:       make(map[string]*droppedDir),
+		pendingRoots:      make(map[string]*pendingRoot),
+		skews:             make(map[string]time.Duration),
+		skippedMounts:     make(map[string]string),
+		unwatchedDirs:     make(map[string]string),
+		watches:           make(map[string]struct{}),
+		anchors:           make(map[string]int),
+		metrics:           newMetrics(),
+		logger:            slog.Default(),
 		Events:            make(chan *WatchEvent, defaultMapSize),
 		Errors:            make(chan error, defaultMapSize),
-		newDirWatchInit:   func(dir string) {},
-		timerDirsChecker:  func(silenceDeadline time.Time) {},
+		reconcileInterval: defaultReconcileInterval,
 		dirFileCountLimit: defaultDirFileCountLimit,
 	}
 
@@ -221,8 +632,13 @@ func New(opts ...Option) (*FileWatcher, error) {
 		}
 	}
 
-	if fileWatcher.method != WatchMethodFS {
-		fileWatcher.timerDirsChecker = fileWatcher.checkDirs
+	if fileWatcher.backend == nil {
+		switch fileWatcher.method {
+		case WatchMethodFS, WatchMethodHybrid:
+			fileWatcher.backend = NewFSBackend(fileWatcher.closeWrite)
+		default:
+			fileWatcher.backend = NewTimerBackend()
+		}
 	}
 
 	if err := fileWatcher.start(); err != nil {
@@ -232,14 +648,57 @@ func New(opts ...Option) (*FileWatcher, error) {
 	return fileWatcher, nil
 }
 
-func (fw *FileWatcher) WatchDir(dir string, includeSub bool, fileMatcher FileMatcher) error {
+// WatchDirOption configures watching a directory.
+type WatchDirOption func(*watchDirOptions)
+
+type watchDirOptions struct {
+	pending bool
+}
+
+// WatchDirPending watches a directory not existing yet once it's created, instead of returning the not exist error.
+// The directory is polled on every check, and scanned as it was watched when created.
+func WatchDirPending() WatchDirOption {
+	return func(o *watchDirOptions) {
+		o.pending = true
+	}
+}
+
+// WatchDir watches the files matched in a directory, and in its sub directories if includeSub is true.
+func (fw *FileWatcher) WatchDir(dir string, includeSub bool, fileMatcher FileMatcher, opts ...WatchDirOption) error {
 	if fileMatcher == nil {
 		return errFileMatcherNil
 	}
 
-	dirInfo, err := os.Stat(dir)
+	var options watchDirOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// the cleaned path is the key of the directory, e.g. for UnwatchDir.
+	dir = filepath.Clean(dir)
+
+	dirInfo, err := fw.fs.Stat(dir)
 	if err != nil {
-		return err
+		if !options.pending || !os.IsNotExist(err) {
+			return err
+		}
+
+		fw.mu.Lock()
+		defer fw.mu.Unlock()
+
+		fw.logger.Info("wait root to be created", "root", dir)
+
+		root := &pendingRoot{
+			includeSub:  includeSub,
+			matcher:     fileMatcher,
+			initialScan: true,
+		}
+		fw.pendingRoots[dir] = root
+
+		// the directory may be created after the stat.
+		fw.anchorPendingRoot(dir, root)
+
+		return nil
 	}
 
 	if !dirInfo.IsDir() {
@@ -250,25 +709,67 @@ func (fw *FileWatcher) WatchDir(dir string, includeSub bool, fileMatcher FileMat
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
-	dirStat := &DirStat{
-		modTime:    dirInfo.ModTime().Add(-time.Second),
-		includeSub: includeSub,
-		matcher:    fileMatcher,
-	}
-	fw.dirs[dir] = dirStat
-	fw.checkDirInfo(dir, dirInfo, dirStat, time.Now().Add(-fw.silenceDuration))
-	fw.newDirWatchInit(dir)
+	// sub directories unwatched before are watched again under the new root.
+	deleteSubPaths(fw.unwatchedDirs, dir)
+
+	// existing sub directories are not notified as created.
+	fw.watchRoot(dir, dirInfo, includeSub, fileMatcher, true)
 
 	return nil
 }
 
-// UnwatchDir stops watching a directory.
-func (fw *FileWatcher) UnwatchDir(dir string) {
+// UnwatchDir stops watching a directory with the sub directories and files under it,
+// Unwatched is sent for each file if enabled. It returns ErrNotWatched if the directory is not watched.
+// A sub directory of a root is not watched again when its parent changes, until its root is watched again.
+func (fw *FileWatcher) UnwatchDir(dir string) error {
+	dir = filepath.Clean(dir)
+
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
-	delete(fw.dirs, dir)
-	delete(fw.newDirs, dir)
+	if root, ok := fw.pendingRoots[dir]; ok {
+		fw.releaseAnchor(root)
+		delete(fw.pendingRoots, dir)
+
+		return nil
+	}
+
+	dirStat, watched := fw.watchedDir(dir)
+	if dropped, ok := fw.droppedDirs[dir]; ok {
+		dirStat, watched = dropped.stat, true
+	}
+
+	if !watched {
+		return fmt.Errorf("%w: %s", ErrNotWatched, dir)
+	}
+
+	files, dirs := fw.subtree(dir)
+
+	for _, f := range files {
+		delete(fw.files, f)
+		delete(fw.newFiles, f)
+
+		if fw.unwatchedEvents {
+			fw.sendEvent(&WatchEvent{
+				Name:  f,
+				Event: Unwatched,
+			})
+		}
+	}
+
+	for _, d := range dirs {
+		fw.dropDir(d)
+	}
+
+	deleteSubPaths(fw.droppedDirs, dir)
+	deleteSubPaths(fw.skippedMounts, dir)
+	deleteSubPaths(fw.unwatchedDirs, dir)
+
+	if dirStat.root != dir {
+		fw.unwatchedDirs[dir] = dirStat.root
+	}
+
+	return nil
 }
 
 // WatchStats holds the current watcher statistics.
@@ -276,6 +777,39 @@ type WatchStats struct {
 	Dirs        int
 	Files       int
 	ActiveFiles int
+
+	// ReconcileFixes is the count of events missed by fsnotify and fixed by the hybrid or overflow rescan.
+	ReconcileFixes int64
+
+	// Events is the count of sent events per event type.
+	Events map[Event]int64
+
+	// DiscardedOnStop is the count of events discarded for the watcher stopped while sending them.
+	// It's not a count of backpressure loss, the watcher blocks until an event is received.
+	DiscardedOnStop int64
+
+	// Errors is the count of errors per kind.
+	Errors map[ErrorKind]int64
+
+	// ScanDuration is the duration of the last timer check, and LastScan the time it started.
+	ScanDuration time.Duration
+	LastScan     time.Time
+
+	// SkippedDirs is the count of directories skipped for more files than the dir file count limit.
+	SkippedDirs int64
+
+	// Watches is the count of directories the backend is watching, e.g. fsnotify watches,
+	// excluding the directories failed to add. It is 0 for polling backends.
+	Watches int
+
+	// PendingRoots is the count of root directories not existing and waiting to be created.
+	PendingRoots int
+
+	// DroppedDirs is the count of directories dropped for an error, including the retrying ones.
+	DroppedDirs int
+
+	// ClockSkews is the clock skew of the file system of each probed root directory, positive if ahead.
+	ClockSkews map[string]time.Duration
 }
 
 // Stats returns the current watcher statistics.
@@ -290,11 +824,33 @@ func (fw *FileWatcher) Stats() WatchStats {
 		}
 	}
 
-	return WatchStats{
-		Dirs:        len(fw.dirs) + len(fw.newDirs),
-		Files:       len(fw.files) + len(fw.newFiles),
-		ActiveFiles: active,
+	stats := WatchStats{
+		Dirs:           len(fw.dirs) + len(fw.newDirs),
+		Files:          len(fw.files) + len(fw.newFiles),
+		ActiveFiles:    active,
+		ReconcileFixes: fw.reconcileFixes,
+		Watches:        fw.watchCount(),
+		PendingRoots:   len(fw.pendingRoots),
+		DroppedDirs:    len(fw.droppedDirs),
+	}
+
+	stats.ClockSkews = make(map[string]time.Duration, len(fw.skews))
+	for root, skew := range fw.skews {
+		stats.ClockSkews[root] = skew
+	}
+
+	fw.metrics.fill(&stats)
+
+	return stats
+}
+
+// watchCount returns the count of directories watched by the backend, excluding the failed to add.
+func (fw *FileWatcher) watchCount() int {
+	if fw.backend.Polling() {
+		return 0
 	}
+
+	return len(fw.watches)
 }
 
 // Done returns a channel that is closed when the watcher is stopped.
@@ -306,79 +862,162 @@ func (fw *FileWatcher) Done() <-chan struct{} {
 func (fw *FileWatcher) Stop() error {
 	fw.runner.Stop()
 
-	if fw.closeFn != nil {
-		return fw.closeFn()
+	return fw.backend.Close()
+}
+
+// backendAdd adds a directory to the backend to receive its change notifications.
+func (fw *FileWatcher) backendAdd(dir string) {
+	if err := fw.backend.Add(dir); err != nil {
+		fw.metrics.error(ErrorKindBackend)
+		fw.logger.Error("backend watch dir error", "path", dir, "err", err)
+
+		return
 	}
 
-	return nil
+	fw.watches[dir] = struct{}{}
 }
 
 // sendEvent sends a watch event without blocking. Drops the event if the watcher is stopped.
 func (fw *FileWatcher) sendEvent(event *WatchEvent) {
 	select {
 	case fw.Events <- event:
+		fw.metrics.event(event.Event, false)
 	case <-fw.runner.C:
+		fw.metrics.event(event.Event, true)
 	}
 }
 
 // sendError sends an error without blocking. Drops the error if the watcher is stopped.
 func (fw *FileWatcher) sendError(err error) {
+	fw.metrics.error(errorKindOf(err))
+
 	select {
 	case fw.Errors <- err:
 	case <-fw.runner.C:
 	}
 }
 
-func (fw *FileWatcher) tryAddNewSubDir(info os.FileInfo, dir string, parentDirStat *DirStat, silenceDeadline time.Time) {
+// tryAddNewSubDir watches a new sub directory, and returns the count of the added directories and files,
+// including the ones under it.
+func (fw *FileWatcher) tryAddNewSubDir(info os.FileInfo, dir string, parentDirStat *DirStat, silenceDeadline time.Time) int {
 	if !parentDirStat.includeSub {
-		return
+		return 0
 	}
 
 	if _, ok := fw.dirs[dir]; ok {
-		return
+		return 0
 	}
 
 	if _, ok := fw.newDirs[dir]; ok {
-		return
+		return 0
+	}
+
+	// a dropped directory is watched again by the retry.
+	if fw.retrying(dir) {
+		return 0
+	}
+
+	if _, ok := fw.unwatchedDirs[dir]; ok {
+		fw.trace("ignore unwatched dir", "root", parentDirStat.root, "path", dir)
+
+		return 0
+	}
+
+	if fw.crossDevice(dir, info, parentDirStat) {
+		return 0
 	}
 
-	vlog.Infof("add new dir: %s", dir)
+	fw.logger.Debug("add new dir", "root", parentDirStat.root, "path", dir)
 
 	newDirStat := &DirStat{
 		modTime:    info.ModTime().Add(-time.Second),
 		includeSub: parentDirStat.includeSub,
 		matcher:    parentDirStat.matcher,
+		root:       parentDirStat.root,
+		dev:        parentDirStat.dev,
+		hasDev:     parentDirStat.hasDev,
 	}
 
 	fw.newDirs[dir] = newDirStat
+	delete(fw.droppedDirs, dir)
+
+	if !fw.initialScan {
+		fw.sendEvent(&WatchEvent{
+			Name:  dir,
+			Event: DirCreate,
+		})
+	}
 
 	// check files and directories in new dir first.
-	fw.checkDirInfo(dir, info, newDirStat, silenceDeadline)
+	return 1 + fw.checkDirInfo(dir, info, newDirStat, silenceDeadline)
 }
 
-func (fw *FileWatcher) tryAddNewFile(path string, fileInfo os.FileInfo, silenceDeadline time.Time) {
+// tryAddNewFile watches a new file, and returns whether it is added.
+func (fw *FileWatcher) tryAddNewFile(path string, fileInfo os.FileInfo, dirStat *DirStat, silenceDeadline time.Time) bool {
 	if _, ok := fw.files[path]; ok {
-		return
+		return false
+	}
+
+	if _, ok := fw.newFiles[path]; ok {
+		return false
+	}
+
+	if filepath.Base(path) == SkewProbeName {
+		return false
 	}
 
-	if !fileInfo.ModTime().After(silenceDeadline) {
-		vlog.Tracef("ignore file(%s) for modTime(%v) reach the silence deadline(%v)",
-			fileInfo.Name(), fileInfo.ModTime(), silenceDeadline)
+	silenceDeadline = fw.skewDeadline(dirStat.root, silenceDeadline)
+	baseline := fw.initialScan && fw.initialScanMode != InitialScanIgnoreOld
 
-		return
+	if !baseline && !fileInfo.ModTime().After(silenceDeadline) {
+		fw.trace("ignore file for mod time reach the silence deadline", "root", dirStat.root, "path", path,
+			"modTime", fileInfo.ModTime(), "silenceDeadline", silenceDeadline)
+
+		return false
 	}
 
-	vlog.Tracef("add new file: %s", path)
+	// an existing file reaching the silence deadline is notified, but not watched like a silenced file.
+	watch := fileInfo.ModTime().After(silenceDeadline)
+
+	if watch {
+		fw.trace("add new file", "root", dirStat.root, "path", path)
+
+		// an existing file not updated in the inactive duration is watched as inactive.
+		inactiveDeadline := fw.skewDeadline(dirStat.root, fw.clock.Now().Add(-fw.inactiveDuration))
+
+		fw.newFiles[path] = &FileStat{
+			active:      !baseline || fileInfo.ModTime().After(inactiveDeadline),
+			modTime:     fileInfo.ModTime(),
+			fingerprint: fingerprintOf(fileInfo),
+			attr:        fileAttrOf(fileInfo),
+			root:        dirStat.root,
+		}
+	} else {
+		fw.trace("notify file without watching for mod time reach the silence deadline", "root", dirStat.root, "path", path)
+	}
 
-	fw.newFiles[path] = &FileStat{
-		active:  true,
-		modTime: fileInfo.ModTime(),
+	event := Create
+	if fw.initialScan && fw.initialScanMode == InitialScanExists {
+		event = Exists
 	}
 
 	fw.sendEvent(&WatchEvent{
 		Name:  path,
-		Event: Create,
+		Event: event,
 	})
+
+	return watch
+}
+
+// watchedFile returns the stat of a watched or newly added file.
+func (fw *FileWatcher) watchedFile(path string) (*FileStat, bool) {
+	if stat, ok := fw.files[path]; ok {
+		return stat, true
+	}
+
+	stat, ok := fw.newFiles[path]
+
+	return stat, ok
 }
 
 func (fw *FileWatcher) tryRemoveFile(path string, _ *DirStat) {
@@ -18,14 +18,18 @@
 package fwatch_test
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/vogo/fwatch"
-	"github.com/vogo/vogo/vlog"
 )
 
 const (
@@ -38,7 +42,7 @@ const (
 )
 
 func TestMain(m *testing.M) {
-	vlog.SetLevel(vlog.LevelDebug)
+	slog.SetLogLoggerLevel(slog.LevelDebug)
 	os.Exit(m.Run())
 }
 
@@ -54,6 +58,11 @@ func TestFileWatcher(t *testing.T) {
 		t.Parallel()
 		doTestTypedFileWatcher(t, fwatch.WatchMethodFS)
 	})
+
+	t.Run("Hybrid", func(t *testing.T) {
+		t.Parallel()
+		doTestTypedFileWatcher(t, fwatch.WatchMethodHybrid)
+	})
 }
 
 func doTestTypedFileWatcher(t *testing.T, method fwatch.WatchMethod) {
@@ -223,6 +232,17 @@ func TestNewOptionValidation(t *testing.T) {
 
 	t.Logf("expected error: %v", err)
 
+	// invalid reconcile interval
+	_, err = fwatch.New(
+		fwatch.WithMethod(fwatch.WatchMethodHybrid),
+		fwatch.WithReconcileInterval(time.Millisecond),
+	)
+	if err == nil {
+		t.Fatal("expected error for too small reconcile interval")
+	}
+
+	t.Logf("expected error: %v", err)
+
 	// valid options
 	w, err := fwatch.New(
 		fwatch.WithMethod(fwatch.WatchMethodFS),
@@ -286,13 +306,19 @@ func TestStatsAndUnwatchDir(t *testing.T) {
 	}
 
 	// unwatch
-	w.UnwatchDir(tempDir)
+	if err = w.UnwatchDir(tempDir); err != nil {
+		t.Fatal(err)
+	}
 
 	stats = w.Stats()
 	t.Logf("[stats after unwatch] dirs=%d, files=%d", stats.Dirs, stats.Files)
 
-	if stats.Dirs != 0 {
-		t.Errorf("expected 0 dirs after unwatch, got %d", stats.Dirs)
+	if stats.Dirs != 0 || stats.Files != 0 {
+		t.Errorf("expected 0 dirs and files after unwatch, got %d, %d", stats.Dirs, stats.Files)
+	}
+
+	if err = w.UnwatchDir(tempDir); !errors.Is(err, fwatch.ErrNotWatched) {
+		t.Errorf("expected ErrNotWatched, got %v", err)
 	}
 }
 
@@ -304,10 +330,22 @@ func TestEventString(t *testing.T) {
 		want  string
 	}{
 		{fwatch.Create, "Create"},
-		{fwatch.Write, "Write"},
+		{fwatch.Active, "Active"},
+		{fwatch.Write, "Active"},
 		{fwatch.Remove, "Remove"},
 		{fwatch.Inactive, "Inactive"},
 		{fwatch.Silence, "Silence"},
+		{fwatch.Overflow, "Overflow"},
+		{fwatch.CloseWrite, "CloseWrite"},
+		{fwatch.Modify, "Modify"},
+		{fwatch.Attrib, "Attrib"},
+		{fwatch.DirCreate, "DirCreate"},
+		{fwatch.DirRemove, "DirRemove"},
+		{fwatch.Exists, "Exists"},
+		{fwatch.Unwatched, "Unwatched"},
+		{fwatch.RootRestored, "RootRestored"},
+		{fwatch.DirResumed, "DirResumed"},
+		{fwatch.DirAbandoned, "DirAbandoned"},
 		{fwatch.Event(0), ""},
 	}
 
@@ -515,3 +553,147 @@ func TestDoneChannel(t *testing.T) {
 		t.Fatal("Done() should be closed after Stop()")
 	}
 }
+
+func TestCloseWrite(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("close write event is only supported on linux")
+	}
+
+	tempDir := t.TempDir()
+
+	w, err := fwatch.New(
+		fwatch.WithMethod(fwatch.WatchMethodFS),
+		fwatch.WithInactiveDuration(10*time.Second),
+		fwatch.WithSilenceDuration(20*time.Second),
+		fwatch.WithCloseWrite(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	if err = w.WatchDir(tempDir, false, func(string) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+
+	filePath := filepath.Join(tempDir, "upload.txt")
+	_ = os.WriteFile(filePath, []byte("data"), filePerm)
+
+	var events []fwatch.Event
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case ev := <-w.Events:
+			t.Logf("[event] %s | %v", ev.Name, ev.Event)
+
+			if ev.Name != filePath {
+				continue
+			}
+
+			events = append(events, ev.Event)
+
+			if ev.Event == fwatch.CloseWrite {
+				if events[0] != fwatch.Create {
+					t.Errorf("expected Create before CloseWrite, got %v", events)
+				}
+
+				return
+			}
+		case watchErr := <-w.Errors:
+			t.Logf("[error] %v", watchErr)
+		case <-timeout:
+			t.Fatalf("timed out waiting for CloseWrite event, got %v", events)
+		}
+	}
+}
+
+func TestCloseWriteOverflow(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("close write event is only supported on linux")
+	}
+
+	if testing.Short() {
+		t.Skip("overflowing the inotify queue writes many files")
+	}
+
+	// more close write events than the default inotify queue size of 16384.
+	const dirCount, fileCount = 20, 1000
+
+	tempDir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+
+	var files []string
+
+	for i := range dirCount {
+		dir := filepath.Join(tempDir, strconv.Itoa(i))
+		_ = os.Mkdir(dir, os.ModePerm)
+
+		for j := range fileCount {
+			f := filepath.Join(dir, strconv.Itoa(j))
+			_ = os.WriteFile(f, nil, filePerm)
+			_ = os.Chtimes(f, old, old)
+			files = append(files, f)
+		}
+	}
+
+	w, err := fwatch.New(
+		fwatch.WithMethod(fwatch.WatchMethodFS),
+		fwatch.WithInactiveDuration(10*time.Second),
+		fwatch.WithSilenceDuration(20*time.Second),
+		fwatch.WithCloseWrite(true),
+		fwatch.WithDirFileCountLimit(1024),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	// the matcher blocks the close write handler, so the inotify queue fills up.
+	var blocking atomic.Bool
+
+	release := make(chan struct{})
+	matcher := func(string) bool {
+		if blocking.Load() {
+			<-release
+		}
+
+		return true
+	}
+
+	for i := range dirCount {
+		if err = w.WatchDir(filepath.Join(tempDir, strconv.Itoa(i)), false, matcher); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blocking.Store(true)
+
+	for _, f := range files {
+		if file, openErr := os.OpenFile(f, os.O_WRONLY, filePerm); openErr == nil {
+			_ = file.Close()
+		}
+	}
+
+	close(release)
+
+	timeout := time.After(10 * time.Second)
+
+	for {
+		select {
+		case ev := <-w.Events:
+			if ev.Event == fwatch.Overflow {
+				return
+			}
+		case watchErr := <-w.Errors:
+			t.Logf("[error] %v", watchErr)
+		case <-timeout:
+			t.Fatal("timed out waiting for Overflow of the close write queue")
+		}
+	}
+}
@@ -0,0 +1,472 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fwatchtest provides a scripted test harness for code built on fwatch.
+// A test changes an in-memory file system, advances a fake clock,
+// and asserts on the exact sequence of watch events, for both timer and fs methods.
+package fwatchtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+)
+
+const (
+	// DefaultInactiveDuration the inactive duration of a harness watcher if not set by options.
+	DefaultInactiveDuration = 2 * time.Second
+
+	// DefaultSilenceDuration the silence duration of a harness watcher if not set by options.
+	DefaultSilenceDuration = 4 * time.Second
+)
+
+// Methods the watch methods a script can run against, see RunMethods.
+var Methods = []fwatch.WatchMethod{fwatch.WatchMethodTimer, fwatch.WatchMethodFS}
+
+// Harness scripts the file system and time of a watcher, and asserts on its events.
+type Harness struct {
+	t testing.TB
+
+	// FS the in-memory file system the watcher scans.
+	FS *fwatch.MemFS
+
+	// Clock the fake clock driving the watcher.
+	Clock *fwatch.FakeClock
+
+	// Watcher the file watcher under test.
+	Watcher *fwatch.FileWatcher
+
+	// backend notifies file system changes in fs method, nil in timer method.
+	backend *memBackend
+
+	// events and errors received from the watcher since the last Events call.
+	mu     sync.Mutex
+	events []fwatch.WatchEvent
+	errs   []error
+
+	// flush requests the collector to receive the buffered events, and closes the channel when done.
+	flush     chan chan struct{}
+	collected chan struct{}
+}
+
+// New creates a harness running a watcher of method over an empty in-memory file system.
+// The clock starts at a fixed time, opts are applied after the harness defaults.
+func New(t testing.TB, method fwatch.WatchMethod, opts ...fwatch.Option) *Harness {
+	t.Helper()
+
+	clock := fwatch.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	memFS := fwatch.NewMemFS()
+	memFS.SetClock(clock)
+
+	h := &Harness{
+		t:         t,
+		FS:        memFS,
+		Clock:     clock,
+		flush:     make(chan chan struct{}),
+		collected: make(chan struct{}),
+	}
+
+	defaults := []fwatch.Option{
+		fwatch.WithMethod(method),
+		fwatch.WithFS(memFS),
+		fwatch.WithClock(clock),
+		fwatch.WithInactiveDuration(DefaultInactiveDuration),
+		fwatch.WithSilenceDuration(DefaultSilenceDuration),
+	}
+
+	if method != fwatch.WatchMethodTimer {
+		h.backend = &memBackend{dirs: make(map[string]bool)}
+		defaults = append(defaults, fwatch.WithBackend(h.backend))
+	}
+
+	watcher, err := fwatch.New(append(defaults, opts...)...)
+	if err != nil {
+		t.Fatalf("fwatchtest: create watcher: %v", err)
+	}
+
+	h.Watcher = watcher
+
+	// events are received continuously, so the watcher never blocks on a full channel.
+	go h.collect()
+
+	t.Cleanup(func() {
+		_ = watcher.Stop()
+		<-h.collected
+	})
+
+	return h
+}
+
+// collect receives the events and errors of the watcher until it's stopped.
+func (h *Harness) collect() {
+	defer close(h.collected)
+
+	for {
+		select {
+		case ev := <-h.Watcher.Events:
+			h.record(ev, nil)
+		case err := <-h.Watcher.Errors:
+			h.record(nil, err)
+		case done := <-h.flush:
+			h.receiveBuffered()
+			close(done)
+		case <-h.Watcher.Done():
+			return
+		}
+	}
+}
+
+// receiveBuffered receives the events and errors already sent without waiting.
+func (h *Harness) receiveBuffered() {
+	for {
+		select {
+		case ev := <-h.Watcher.Events:
+			h.record(ev, nil)
+		case err := <-h.Watcher.Errors:
+			h.record(nil, err)
+		default:
+			return
+		}
+	}
+}
+
+func (h *Harness) record(ev *fwatch.WatchEvent, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ev != nil {
+		h.events = append(h.events, *ev)
+	}
+
+	if err != nil {
+		h.errs = append(h.errs, err)
+	}
+}
+
+// RunMethods runs script as a sub test for each of Methods.
+func RunMethods(t *testing.T, script func(t *testing.T, h *Harness), opts ...fwatch.Option) {
+	t.Helper()
+
+	for _, method := range Methods {
+		t.Run(string(method), func(t *testing.T) {
+			script(t, New(t, method, opts...))
+		})
+	}
+}
+
+// Watch starts watching dir, failing the test on error.
+func (h *Harness) Watch(dir string, includeSub bool, matcher fwatch.FileMatcher) {
+	h.t.Helper()
+
+	if err := h.Watcher.WatchDir(dir, includeSub, matcher); err != nil {
+		h.t.Fatalf("fwatchtest: watch %s: %v", dir, err)
+	}
+}
+
+// Advance moves the clock forward by d, returning after the watcher handled all ticks on the way.
+func (h *Harness) Advance(d time.Duration) {
+	h.Clock.Advance(d)
+}
+
+// MkdirAll creates a directory and its missing parents.
+func (h *Harness) MkdirAll(dir string) {
+	h.t.Helper()
+
+	var missing []string
+
+	for p := filepath.Clean(dir); !h.exists(p); p = filepath.Dir(p) {
+		missing = append([]string{p}, missing...)
+	}
+
+	h.check(h.FS.MkdirAll(dir))
+
+	for _, p := range missing {
+		h.notify(p, fwatch.OpCreate)
+	}
+}
+
+// WriteFile writes data to a file, creating it if not exists.
+func (h *Harness) WriteFile(name string, data []byte) {
+	h.t.Helper()
+
+	op := h.createOrWrite(name)
+	h.check(h.FS.WriteFile(name, data))
+	h.notify(name, op)
+}
+
+// AppendFile appends data to a file, creating it if not exists.
+func (h *Harness) AppendFile(name string, data []byte) {
+	h.t.Helper()
+
+	op := h.createOrWrite(name)
+	h.check(h.FS.AppendFile(name, data))
+	h.notify(name, op)
+}
+
+// Rename moves a file or a directory.
+func (h *Harness) Rename(oldName, newName string) {
+	h.t.Helper()
+
+	h.check(h.FS.Rename(oldName, newName))
+	h.notify(oldName, fwatch.OpRename)
+	h.notify(newName, fwatch.OpCreate)
+}
+
+// Remove removes a file or an empty directory.
+func (h *Harness) Remove(name string) {
+	h.t.Helper()
+
+	h.check(h.FS.Remove(name))
+	h.notify(name, fwatch.OpRemove)
+}
+
+// RemoveAll removes a file or a directory with all its children.
+func (h *Harness) RemoveAll(name string) {
+	h.t.Helper()
+
+	h.check(h.FS.RemoveAll(name))
+	h.notify(name, fwatch.OpRemove)
+}
+
+// Symlink creates a symbolic link name pointing to target.
+func (h *Harness) Symlink(target, name string) {
+	h.t.Helper()
+
+	h.check(h.FS.Symlink(target, name))
+	h.notify(name, fwatch.OpCreate)
+}
+
+// Mknod creates a special file of the type of mode, e.g. os.ModeNamedPipe.
+func (h *Harness) Mknod(name string, mode os.FileMode) {
+	h.t.Helper()
+
+	h.check(h.FS.Mknod(name, mode))
+	h.notify(name, fwatch.OpCreate)
+}
+
+// Chtimes sets the mod time of a file or a directory.
+func (h *Harness) Chtimes(name string, modTime time.Time) {
+	h.t.Helper()
+
+	h.check(h.FS.Chtimes(name, modTime))
+	h.notify(name, fwatch.OpChmod)
+}
+
+// Chmod sets the permission bits of a file or a directory.
+func (h *Harness) Chmod(name string, mode os.FileMode) {
+	h.t.Helper()
+
+	h.check(h.FS.Chmod(name, mode))
+	h.notify(name, fwatch.OpChmod)
+}
+
+// Chown sets the owner of a file or a directory.
+func (h *Harness) Chown(name string, uid, gid int) {
+	h.t.Helper()
+
+	h.check(h.FS.Chown(name, uid, gid))
+	h.notify(name, fwatch.OpChmod)
+}
+
+// Events returns the events sent by the watcher since the last call without waiting.
+// Errors sent by the watcher are logged.
+func (h *Harness) Events() []fwatch.WatchEvent {
+	// the events sent before are either collected or still buffered in the channel.
+	done := make(chan struct{})
+
+	select {
+	case h.flush <- done:
+		<-done
+	case <-h.collected:
+	}
+
+	h.mu.Lock()
+	events, errs := h.events, h.errs
+	h.events, h.errs = nil, nil
+	h.mu.Unlock()
+
+	for _, err := range errs {
+		h.t.Logf("fwatchtest: watch error: %v", err)
+	}
+
+	return events
+}
+
+// ExpectEvents asserts the events sent since the last call are exactly want,
+// comparing the name and event type in order, and reports a line diff otherwise.
+func (h *Harness) ExpectEvents(want ...fwatch.WatchEvent) {
+	h.t.Helper()
+
+	if diff := DiffEvents(want, h.Events()); diff != "" {
+		h.t.Errorf("fwatchtest: events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// ExpectNoEvents asserts no events were sent since the last call.
+func (h *Harness) ExpectNoEvents() {
+	h.t.Helper()
+
+	h.ExpectEvents()
+}
+
+// Event is a shorthand to build an expected watch event.
+func Event(event fwatch.Event, name string) fwatch.WatchEvent {
+	return fwatch.WatchEvent{Name: name, Event: event}
+}
+
+// DiffEvents returns a line diff of the want and got event sequences, empty if equal.
+func DiffEvents(want, got []fwatch.WatchEvent) string {
+	wantLines := formatEvents(want)
+	gotLines := formatEvents(got)
+
+	// longest common subsequence table.
+	lcs := make([][]int, len(wantLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(gotLines)+1)
+	}
+
+	for i := len(wantLines) - 1; i >= 0; i-- {
+		for j := len(gotLines) - 1; j >= 0; j-- {
+			if wantLines[i] == gotLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var (
+		sb    strings.Builder
+		equal = true
+		i, j  int
+	)
+
+	for i < len(wantLines) || j < len(gotLines) {
+		switch {
+		case i < len(wantLines) && j < len(gotLines) && wantLines[i] == gotLines[j]:
+			sb.WriteString("  " + wantLines[i] + "\n")
+			i++
+			j++
+		case j < len(gotLines) && (i == len(wantLines) || lcs[i][j+1] >= lcs[i+1][j]):
+			sb.WriteString("+ " + gotLines[j] + "\n")
+			j++
+			equal = false
+		default:
+			sb.WriteString("- " + wantLines[i] + "\n")
+			i++
+			equal = false
+		}
+	}
+
+	if equal {
+		return ""
+	}
+
+	return sb.String()
+}
+
+func formatEvents(events []fwatch.WatchEvent) []string {
+	lines := make([]string, len(events))
+
+	for i, ev := range events {
+		lines[i] = fmt.Sprintf("%s %s", ev.Event, ev.Name)
+	}
+
+	return lines
+}
+
+func (h *Harness) check(err error) {
+	h.t.Helper()
+
+	if err != nil {
+		h.t.Fatalf("fwatchtest: %v", err)
+	}
+}
+
+func (h *Harness) exists(name string) bool {
+	_, err := h.FS.Lstat(name)
+
+	return err == nil
+}
+
+func (h *Harness) createOrWrite(name string) fwatch.Op {
+	if h.exists(name) {
+		return fwatch.OpWrite
+	}
+
+	return fwatch.OpCreate
+}
+
+// notify delivers a change to the watcher synchronously in fs method.
+func (h *Harness) notify(name string, op fwatch.Op) {
+	if h.backend != nil {
+		h.backend.notify(filepath.Clean(name), op)
+	}
+}
+
+// memBackend a backend notifying the changes made through the harness,
+// for the directories added by the watcher.
+type memBackend struct {
+	mu   sync.Mutex
+	sink fwatch.BackendSink
+	dirs map[string]bool
+}
+
+func (b *memBackend) Polling() bool { return false }
+
+func (b *memBackend) Start(sink fwatch.BackendSink) error {
+	b.sink = sink
+
+	return nil
+}
+
+func (b *memBackend) Add(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.dirs[dir] = true
+
+	return nil
+}
+
+func (b *memBackend) Remove(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.dirs, dir)
+
+	return nil
+}
+
+func (b *memBackend) Close() error { return nil }
+
+func (b *memBackend) notify(name string, op fwatch.Op) {
+	b.mu.Lock()
+	// like fsnotify, a removed or renamed directory notifies itself.
+	watched := b.dirs[filepath.Dir(name)] || (b.dirs[name] && (op.Has(fwatch.OpRemove) || op.Has(fwatch.OpRename)))
+	b.mu.Unlock()
+
+	if watched {
+		b.sink.HandleEvent(fwatch.BackendEvent{Name: name, Op: op})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatchtest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func logMatcher(name string) bool {
+	return strings.HasSuffix(name, ".log")
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs/sub")
+		h.Watch("/logs", true, logMatcher)
+		h.Advance(time.Second / 2)
+
+		h.WriteFile("/logs/a.log", []byte("a"))
+		h.WriteFile("/logs/a.txt", []byte("a"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/a.log"))
+
+		h.WriteFile("/logs/sub/b.log", []byte("b"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/sub/b.log"))
+
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Inactive, "/logs/a.log"))
+
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Inactive, "/logs/sub/b.log"))
+
+		h.AppendFile("/logs/a.log", []byte("b"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Active, "/logs/a.log"))
+
+		h.Advance(4 * time.Second)
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.Silence, "/logs/sub/b.log"),
+			fwatchtest.Event(fwatch.Inactive, "/logs/a.log"),
+			fwatchtest.Event(fwatch.Silence, "/logs/a.log"),
+		)
+
+		h.WriteFile("/logs/c.log", []byte("c"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/c.log"))
+
+		h.Remove("/logs/c.log")
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Remove, "/logs/c.log"))
+		h.ExpectNoEvents()
+	})
+}
+
+func TestManyEvents(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs")
+		h.Watch("/logs", false, logMatcher)
+		h.Advance(time.Second / 2)
+
+		// more events than the buffer of the events channel.
+		for i := range 40 {
+			h.WriteFile(fmt.Sprintf("/logs/%d.log", i), []byte("x"))
+		}
+
+		h.Advance(time.Second)
+
+		if events := h.Events(); len(events) != 40 {
+			t.Errorf("want 40 events, got %d", len(events))
+		}
+	})
+}
+
+func TestDiffEvents(t *testing.T) {
+	t.Parallel()
+
+	want := []fwatch.WatchEvent{
+		fwatchtest.Event(fwatch.Create, "/a"),
+		fwatchtest.Event(fwatch.Inactive, "/a"),
+	}
+	got := []fwatch.WatchEvent{
+		fwatchtest.Event(fwatch.Create, "/a"),
+		fwatchtest.Event(fwatch.Remove, "/a"),
+	}
+
+	if diff := fwatchtest.DiffEvents(want, want); diff != "" {
+		t.Errorf("expected no diff, got:\n%s", diff)
+	}
+
+	wantDiff := "  Create /a\n+ Remove /a\n- Inactive /a\n"
+	if diff := fwatchtest.DiffEvents(want, got); diff != wantDiff {
+		t.Errorf("DiffEvents =\n%s\nwant\n%s", diff, wantDiff)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestInitialScan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		mode fwatch.InitialScan
+		want []fwatch.WatchEvent
+	}{
+		{
+			name: "ignore old",
+			mode: fwatch.InitialScanIgnoreOld,
+			want: []fwatch.WatchEvent{
+				fwatchtest.Event(fwatch.Create, "/logs/new.log"),
+			},
+		},
+		{
+			name: "create",
+			mode: fwatch.InitialScanCreate,
+			want: []fwatch.WatchEvent{
+				fwatchtest.Event(fwatch.Create, "/logs/new.log"),
+				fwatchtest.Event(fwatch.Create, "/logs/old.log"),
+				fwatchtest.Event(fwatch.Create, "/logs/sub/old.log"),
+			},
+		},
+		{
+			name: "exists",
+			mode: fwatch.InitialScanExists,
+			want: []fwatch.WatchEvent{
+				fwatchtest.Event(fwatch.Exists, "/logs/new.log"),
+				fwatchtest.Event(fwatch.Exists, "/logs/old.log"),
+				fwatchtest.Event(fwatch.Exists, "/logs/sub/old.log"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := fwatchtest.New(t, fwatch.WatchMethodTimer, fwatch.WithInitialScan(tt.mode))
+
+			h.MkdirAll("/logs/sub")
+			h.WriteFile("/logs/old.log", []byte("old"))
+			h.Chtimes("/logs/old.log", h.Clock.Now().Add(-time.Hour))
+			h.WriteFile("/logs/sub/old.log", []byte("old"))
+			h.Chtimes("/logs/sub/old.log", h.Clock.Now().Add(-time.Hour))
+			h.WriteFile("/logs/new.log", []byte("new"))
+
+			h.Watch("/logs", true, func(string) bool { return true })
+			h.ExpectEvents(tt.want...)
+
+			// files created after watching are always notified by Create.
+			h.Advance(time.Second / 2)
+			h.WriteFile("/logs/next.log", []byte("next"))
+			h.Advance(time.Second)
+			h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/next.log"))
+		})
+	}
+
+	if _, err := fwatch.New(fwatch.WithInitialScan(fwatch.InitialScanExists + 1)); err == nil {
+		t.Fatal("expected error for invalid initial scan mode")
+	}
+}
+
+func TestInitialScanOldFiles(t *testing.T) {
+	t.Parallel()
+
+	h := fwatchtest.New(t, fwatch.WatchMethodTimer, fwatch.WithInitialScan(fwatch.InitialScanExists))
+
+	h.MkdirAll("/logs")
+	h.WriteFile("/logs/new.log", []byte("new"))
+	h.WriteFile("/logs/idle.log", []byte("idle"))
+	h.Chtimes("/logs/idle.log", h.Clock.Now().Add(-3*time.Second))
+	h.WriteFile("/logs/old.log", []byte("old"))
+	h.Chtimes("/logs/old.log", h.Clock.Now().Add(-24*time.Hour))
+
+	h.Watch("/logs", false, func(string) bool { return true })
+	h.ExpectEvents(
+		fwatchtest.Event(fwatch.Exists, "/logs/idle.log"),
+		fwatchtest.Event(fwatch.Exists, "/logs/new.log"),
+		fwatchtest.Event(fwatch.Exists, "/logs/old.log"),
+	)
+
+	tests := []struct {
+		path  string
+		state fwatch.State
+		ok    bool
+	}{
+		{"/logs/new.log", fwatch.StateActive, true},
+		{"/logs/idle.log", fwatch.StateInactive, true},
+		{"/logs/old.log", fwatch.StateUntracked, false},
+	}
+
+	for _, tt := range tests {
+		if state, ok := h.Watcher.FileState(tt.path); ok != tt.ok || state.State != tt.state {
+			t.Errorf("unexpected state of %s: %+v, %v", tt.path, state, ok)
+		}
+	}
+
+	// the idle file is silenced without an Inactive event, the old file is not notified again.
+	h.Advance(time.Second)
+	h.ExpectNoEvents()
+
+	h.Advance(time.Second)
+	h.ExpectEvents(fwatchtest.Event(fwatch.Silence, "/logs/idle.log"))
+
+	h.Advance(time.Second)
+	h.ExpectEvents(fwatchtest.Event(fwatch.Inactive, "/logs/new.log"))
+
+	h.Advance(5 * time.Second)
+	h.ExpectEvents(fwatchtest.Event(fwatch.Silence, "/logs/new.log"))
+}
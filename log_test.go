@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: fwatch.LevelTrace}))
+	h := fwatchtest.New(t, fwatch.WatchMethodTimer, fwatch.WithLogger(logger))
+
+	h.MkdirAll("/logs/sub")
+	h.WriteFile("/logs/a.txt", []byte("a"))
+	h.Watch("/logs", true, func(name string) bool { return strings.HasSuffix(name, ".log") })
+
+	want := map[string]string{
+		"ignore file for not match": "/logs/a.txt",
+		"add new dir":               "/logs/sub",
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log record %s: %v", line, err)
+		}
+
+		msg, _ := record["msg"].(string)
+		if path, ok := want[msg]; ok && record["path"] == path && record["root"] == "/logs" {
+			delete(want, msg)
+		}
+	}
+
+	if len(want) > 0 {
+		t.Errorf("missing logs %v in:\n%s", want, buf.String())
+	}
+
+	if _, err := fwatch.New(fwatch.WithLogger(nil)); err == nil {
+		t.Fatal("expected error for nil logger")
+	}
+}
+
+func TestBackendLogger(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+	backend := &manualBackend{}
+
+	w, err := fwatch.New(fwatch.WithBackend(backend), fwatch.WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	if backend.sink.Logger() != logger {
+		t.Errorf("backend not started with the logger of the watcher")
+	}
+}
@@ -0,0 +1,566 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errTooManyLinks = errors.New("too many links")
+
+// memNode a file, directory or symbolic link in a MemFS.
+type memNode struct {
+	mode    fs.FileMode
+	data    []byte
+	modTime time.Time
+	ctime   time.Time
+	inode   uint64
+	uid     int
+	gid     int
+	dev     uint64
+	target  string
+}
+
+// MemFS an in-memory file system for tests, names are cleaned absolute paths like "/logs/app.log".
+// Creating or removing an entry updates the mod time of its parent directory like a real file system.
+type MemFS struct {
+	mu        sync.Mutex
+	nodes     map[string]*memNode
+	now       func() time.Time
+	lastInode uint64
+}
+
+// NewMemFS creates an in-memory file system with an empty root directory.
+func NewMemFS() *MemFS {
+	m := &MemFS{
+		nodes: make(map[string]*memNode, defaultMapSize),
+		now:   time.Now,
+	}
+
+	m.create(string(filepath.Separator), &memNode{mode: fs.ModeDir | 0o755})
+
+	return m
+}
+
+// SetClock sets the clock to get the mod time of changed files from, default is the os clock.
+func (m *MemFS) SetClock(clock Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = clock.Now
+}
+
+// MkdirAll creates a directory and all its missing parents.
+func (m *MemFS) MkdirAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+
+	var missing []string
+
+	for p := name; ; p = filepath.Dir(p) {
+		if node, ok := m.nodes[p]; ok {
+			if !node.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrExist}
+			}
+
+			break
+		}
+
+		missing = append(missing, p)
+	}
+
+	for _, p := range slices.Backward(missing) {
+		m.create(p, &memNode{mode: fs.ModeDir | 0o755})
+	}
+
+	return nil
+}
+
+// WriteFile writes data to a file, creating it if not exists, and updates its mod time.
+func (m *MemFS) WriteFile(name string, data []byte) error {
+	return m.write("write", name, data, false)
+}
+
+// AppendFile appends data to a file, creating it if not exists, and updates its mod time.
+func (m *MemFS) AppendFile(name string, data []byte) error {
+	return m.write("append", name, data, true)
+}
+
+func (m *MemFS) write(op, name string, data []byte, appendData bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	node, ok := m.nodes[name]
+	if !ok {
+		if err = m.checkParent(name); err != nil {
+			return &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		m.create(name, &memNode{mode: 0o644, data: slices.Clone(data)})
+
+		return nil
+	}
+
+	if node.mode.IsDir() {
+		return &fs.PathError{Op: op, Path: name, Err: errors.New("is a directory")}
+	}
+
+	if appendData {
+		node.data = append(node.data, data...)
+	} else {
+		node.data = slices.Clone(data)
+	}
+
+	node.modTime = m.now()
+	node.ctime = node.modTime
+
+	return nil
+}
+
+// Symlink creates a symbolic link name pointing to target.
+func (m *MemFS) Symlink(target, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+
+	if _, ok := m.nodes[name]; ok {
+		return &fs.PathError{Op: "symlink", Path: name, Err: fs.ErrExist}
+	}
+
+	if err := m.checkParent(name); err != nil {
+		return &fs.PathError{Op: "symlink", Path: name, Err: err}
+	}
+
+	m.create(name, &memNode{mode: fs.ModeSymlink | 0o777, target: target})
+
+	return nil
+}
+
+// Mknod creates a special file name of the type of mode, e.g. os.ModeNamedPipe or os.ModeSocket.
+func (m *MemFS) Mknod(name string, mode fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+
+	if _, ok := m.nodes[name]; ok {
+		return &fs.PathError{Op: "mknod", Path: name, Err: fs.ErrExist}
+	}
+
+	if err := m.checkParent(name); err != nil {
+		return &fs.PathError{Op: "mknod", Path: name, Err: err}
+	}
+
+	m.create(name, &memNode{mode: mode&(fs.ModeNamedPipe|fs.ModeSocket|fs.ModeDevice|fs.ModeCharDevice) | 0o644})
+
+	return nil
+}
+
+// Rename moves a file or a directory with all its children.
+func (m *MemFS) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldName, newName = filepath.Clean(oldName), filepath.Clean(newName)
+
+	if _, ok := m.nodes[oldName]; !ok {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrNotExist}
+	}
+
+	if err := m.checkParent(newName); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+
+	moved := make(map[string]*memNode)
+	prefix := oldName + string(filepath.Separator)
+
+	for p, node := range m.nodes {
+		if p == oldName || strings.HasPrefix(p, prefix) {
+			moved[newName+strings.TrimPrefix(p, oldName)] = node
+		}
+	}
+
+	m.removeTree(newName)
+	m.removeTree(oldName)
+
+	now := m.now()
+
+	for p, node := range moved {
+		node.ctime = now
+		m.nodes[p] = node
+	}
+
+	if parent, ok := m.nodes[filepath.Dir(newName)]; ok {
+		parent.modTime = m.now()
+	}
+
+	return nil
+}
+
+// Remove removes a file or an empty directory.
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+
+	node, ok := m.nodes[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if node.mode.IsDir() && len(m.children(name)) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+	}
+
+	m.removeTree(name)
+
+	return nil
+}
+
+// RemoveAll removes a file or a directory with all its children.
+func (m *MemFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeTree(filepath.Clean(name))
+
+	return nil
+}
+
+// Chtimes sets the mod time of a file or a directory.
+func (m *MemFS) Chtimes(name string, modTime time.Time) error {
+	return m.change("chtimes", name, func(node *memNode) {
+		node.modTime = modTime
+	})
+}
+
+// Chmod sets the permission bits of a file or a directory.
+func (m *MemFS) Chmod(name string, mode fs.FileMode) error {
+	return m.change("chmod", name, func(node *memNode) {
+		node.mode = node.mode.Type() | mode.Perm()
+	})
+}
+
+// Chown sets the owner of a file or a directory.
+func (m *MemFS) Chown(name string, uid, gid int) error {
+	return m.change("chown", name, func(node *memNode) {
+		node.uid = uid
+		node.gid = gid
+	})
+}
+
+// Mount simulates a file system mounted on directory name, by setting the device id
+// of the directory and the entries under it to dev. New entries inherit the device id of their parent.
+func (m *MemFS) Mount(name string, dev uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+
+	node, ok := m.nodes[name]
+	if !ok {
+		return &fs.PathError{Op: "mount", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if !node.mode.IsDir() {
+		return &fs.PathError{Op: "mount", Path: name, Err: errors.New("not a directory")}
+	}
+
+	for p, n := range m.nodes {
+		if isSubPath(name, p) {
+			n.dev = dev
+		}
+	}
+
+	return nil
+}
+
+// change applies fn to the node of name following symbolic links, and updates its ctime.
+func (m *MemFS) change(op, name string, fn func(node *memNode)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resolved, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	node, ok := m.nodes[resolved]
+	if !ok {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	fn(node)
+	node.ctime = m.now()
+
+	return nil
+}
+
+// Stat returns the file info of name, following symbolic links.
+func (m *MemFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resolved, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	return m.info("stat", resolved, filepath.Base(name))
+}
+
+// Lstat returns the file info of name, not following symbolic links.
+func (m *MemFS) Lstat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+
+	return m.info("lstat", name, filepath.Base(name))
+}
+
+// ReadDir returns the entries of a directory sorted by file name.
+func (m *MemFS) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	node, ok := m.nodes[dir]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	children := m.children(dir)
+	entries := make([]os.DirEntry, 0, len(children))
+
+	for _, child := range children {
+		info, _ := m.info("readdir", child, filepath.Base(child))
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	return entries, nil
+}
+
+// Open opens a file for reading the content at the time of opening.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resolved, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	info, err := m.info("open", resolved, filepath.Base(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &memFile{
+		Reader: bytes.NewReader(m.nodes[resolved].data),
+		info:   info,
+	}, nil
+}
+
+// EvalSymlinks returns the path name after resolving all symbolic links.
+func (m *MemFS) EvalSymlinks(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resolved, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return "", &fs.PathError{Op: "evalsymlinks", Path: name, Err: err}
+	}
+
+	if _, ok := m.nodes[resolved]; !ok {
+		return "", &fs.PathError{Op: "evalsymlinks", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return resolved, nil
+}
+
+// resolve follows the symbolic links of all path elements of name.
+func (m *MemFS) resolve(name string) (string, error) {
+	hops := 0
+
+	return m.resolveHops(name, &hops)
+}
+
+func (m *MemFS) resolveHops(name string, hops *int) (string, error) {
+	resolved := string(filepath.Separator)
+
+	for _, elem := range strings.Split(strings.TrimPrefix(name, resolved), string(filepath.Separator)) {
+		if elem == "" {
+			continue
+		}
+
+		resolved = filepath.Join(resolved, elem)
+
+		for {
+			node, ok := m.nodes[resolved]
+			if !ok || node.mode&fs.ModeSymlink == 0 {
+				break
+			}
+
+			if *hops++; *hops > maxSymlinkHops {
+				return name, errTooManyLinks
+			}
+
+			target := node.target
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(resolved), target)
+			}
+
+			var err error
+
+			if resolved, err = m.resolveHops(filepath.Clean(target), hops); err != nil {
+				return name, err
+			}
+		}
+	}
+
+	return resolved, nil
+}
+
+func (m *MemFS) checkParent(name string) error {
+	parent, ok := m.nodes[filepath.Dir(name)]
+	if !ok {
+		return fs.ErrNotExist
+	}
+
+	if !parent.mode.IsDir() {
+		return errors.New("not a directory")
+	}
+
+	return nil
+}
+
+// create adds a node and updates the mod time of its parent.
+func (m *MemFS) create(name string, node *memNode) {
+	now := m.now()
+	m.lastInode++
+	node.modTime = now
+	node.ctime = now
+	node.inode = m.lastInode
+	m.nodes[name] = node
+
+	if parent, ok := m.nodes[filepath.Dir(name)]; ok {
+		parent.modTime = now
+		node.dev = parent.dev
+	}
+}
+
+// removeTree removes a node with all its children, and updates the mod time of its parent.
+func (m *MemFS) removeTree(name string) {
+	if _, ok := m.nodes[name]; !ok {
+		return
+	}
+
+	prefix := name + string(filepath.Separator)
+
+	for p := range m.nodes {
+		if p == name || strings.HasPrefix(p, prefix) {
+			delete(m.nodes, p)
+		}
+	}
+
+	if parent, ok := m.nodes[filepath.Dir(name)]; ok {
+		parent.modTime = m.now()
+	}
+}
+
+// children returns the sorted paths of direct children of dir.
+func (m *MemFS) children(dir string) []string {
+	var children []string
+
+	for p := range m.nodes {
+		if p != dir && filepath.Dir(p) == dir {
+			children = append(children, p)
+		}
+	}
+
+	slices.Sort(children)
+
+	return children
+}
+
+func (m *MemFS) info(op, name, baseName string) (os.FileInfo, error) {
+	node, ok := m.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &memFileInfo{
+		name:    baseName,
+		size:    int64(len(node.data)),
+		mode:    node.mode,
+		modTime: node.modTime,
+		sys:     &SysStat{Inode: node.inode, Ctime: node.ctime, UID: node.uid, GID: node.gid, Dev: node.dev},
+	}, nil
+}
+
+// memFileInfo the file info of a MemFS node.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	sys     *SysStat
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return i.size }
+func (i *memFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *memFileInfo) ModTime() time.Time { return i.modTime }
+func (i *memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memFileInfo) Sys() any           { return i.sys }
+
+// memFile an opened MemFS file, supporting io.ReaderAt and io.Seeker like os.File.
+type memFile struct {
+	*bytes.Reader
+	info os.FileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *memFile) Close() error { return nil }
@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/vogo/fwatch"
+)
+
+func TestMemFS(t *testing.T) {
+	t.Parallel()
+
+	m := fwatch.NewMemFS()
+
+	if err := m.MkdirAll("/logs/sub"); err != nil {
+		t.Fatal(err)
+	}
+
+	dirModTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	_ = m.Chtimes("/logs", dirModTime)
+
+	// creating a file updates the mod time of its parent.
+	if err := m.WriteFile("/logs/a.log", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, _ := m.Stat("/logs"); !info.ModTime().After(dirModTime) {
+		t.Errorf("expected dir mod time updated, got %v", info.ModTime())
+	}
+
+	// writing into a missing directory fails.
+	if err := m.WriteFile("/missing/a.log", nil); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+
+	_ = m.AppendFile("/logs/a.log", []byte("bc"))
+
+	if info, _ := m.Stat("/logs/a.log"); info.Size() != 3 {
+		t.Errorf("expected size 3, got %d", info.Size())
+	}
+
+	// symlinks are resolved.
+	_ = m.Symlink("sub", "/logs/link")
+
+	if resolved, err := m.EvalSymlinks("/logs/link"); err != nil || resolved != "/logs/sub" {
+		t.Errorf("EvalSymlinks = %s, %v, want /logs/sub", resolved, err)
+	}
+
+	if info, _ := m.Lstat("/logs/link"); info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("expected symlink mode, got %v", info.Mode())
+	}
+
+	// renaming a directory moves its children.
+	_ = m.WriteFile("/logs/sub/b.log", []byte("b"))
+
+	if err := m.Rename("/logs/sub", "/logs/moved"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Stat("/logs/moved/b.log"); err != nil {
+		t.Errorf("expected moved file, got %v", err)
+	}
+
+	if _, err := m.Stat("/logs/link"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected dangling link, got %v", err)
+	}
+
+	if err := m.Remove("/logs/moved"); err == nil {
+		t.Error("expected error removing non-empty dir")
+	}
+
+	_ = m.RemoveAll("/logs/moved")
+
+	entries, _ := m.ReadDir("/logs")
+	if len(entries) != 2 || entries[0].Name() != "a.log" || entries[1].Name() != "link" {
+		t.Errorf("unexpected entries: %v", entries)
+	}
+}
+
+func TestWatchMemFS(t *testing.T) {
+	t.Parallel()
+
+	m := fwatch.NewMemFS()
+	_ = m.MkdirAll("/logs")
+	_ = m.WriteFile("/logs/old.log", []byte("x"))
+	_ = m.Chtimes("/logs/old.log", time.Now().Add(-time.Hour))
+	_ = m.WriteFile("/logs/new.log", []byte("x"))
+
+	w, err := fwatch.New(
+		fwatch.WithFS(m),
+		fwatch.WithInactiveDuration(10*time.Second),
+		fwatch.WithSilenceDuration(20*time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Stop() }()
+
+	if err = w.WatchDir("/logs", false, func(string) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+
+	waitEvent(t, w, "/logs/new.log", fwatch.Create)
+
+	_ = m.WriteFile("/logs/created.log", []byte("x"))
+	waitEvent(t, w, "/logs/created.log", fwatch.Create)
+
+	_ = m.Remove("/logs/new.log")
+	waitEvent(t, w, "/logs/new.log", fwatch.Remove)
+
+	if stats := w.Stats(); stats.Files != 1 {
+		t.Errorf("expected 1 file, got %d", stats.Files)
+	}
+}
+
+func TestIOFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fwatch.IOFS(fstest.MapFS{
+		"logs/a.log":  {Data: []byte("a"), ModTime: time.Now()},
+		"logs/link":   {Data: []byte("a.log"), Mode: fs.ModeSymlink},
+		"logs/b/c.go": {Data: []byte("c")},
+	})
+
+	entries, err := fsys.ReadDir("logs")
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("ReadDir = %v, %v", entries, err)
+	}
+
+	if resolved, err := fsys.EvalSymlinks("logs/link"); err != nil || resolved != "logs/a.log" {
+		t.Errorf("EvalSymlinks = %s, %v, want logs/a.log", resolved, err)
+	}
+}
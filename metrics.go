@@ -0,0 +1,242 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"errors"
+	"expvar"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// ErrorKind the kind of an error counted in metrics.
+type ErrorKind string
+
+// These are the kinds of errors counted in metrics.
+const (
+	// ErrorKindTooManyFiles a directory has more files than the dir file count limit.
+	ErrorKindTooManyFiles ErrorKind = "too_many_files"
+
+	// ErrorKindPermission a directory or file is not permitted to read.
+	ErrorKindPermission ErrorKind = "permission"
+
+	// ErrorKindOverflow the backend dropped notifications.
+	ErrorKindOverflow ErrorKind = "overflow"
+
+	// ErrorKindBackend the backend failed to watch a directory or reported an error.
+	ErrorKindBackend ErrorKind = "backend"
+
+	// ErrorKindOther any other error.
+	ErrorKindOther ErrorKind = "other"
+)
+
+func errorKindOf(err error) ErrorKind {
+	switch {
+	case errors.Is(err, ErrTooManyDirFile):
+		return ErrorKindTooManyFiles
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorKindPermission
+	case errors.Is(err, ErrEventOverflow):
+		return ErrorKindOverflow
+	}
+
+	return ErrorKindOther
+}
+
+// MetricsSink receives the operational metrics of a watcher, e.g. to export them to a monitoring system.
+// The methods are called synchronously by the watcher, and should not block.
+type MetricsSink interface {
+	// Event is called for every event, discarded is true if it's not delivered for the watcher stopped.
+	// Events are never dropped for backpressure, the watcher blocks until they're received.
+	Event(e Event, discarded bool)
+
+	// Error is called for every error.
+	Error(kind ErrorKind)
+
+	// Scan is called after every timer check with its duration.
+	Scan(duration time.Duration)
+
+	// SkippedDir is called when a directory is skipped for more files than the dir file count limit.
+	SkippedDir(dir string)
+
+	// ReconcileFixes is called with the count of events missed by the backend and fixed by rescan.
+	ReconcileFixes(n int)
+
+	// Watches is called after every timer check with the count of directories watched by the backend.
+	Watches(n int)
+}
+
+// metrics collects the operational metrics of a watcher, and forwards them to the sink if set.
+type metrics struct {
+	mu sync.Mutex
+
+	sink MetricsSink
+
+	events          map[Event]int64
+	discardedOnStop int64
+	errors          map[ErrorKind]int64
+	scanDuration    time.Duration
+	lastScan        time.Time
+	skippedDirs     int64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		events: make(map[Event]int64),
+		errors: make(map[ErrorKind]int64),
+	}
+}
+
+func (m *metrics) event(e Event, discarded bool) {
+	m.mu.Lock()
+	if discarded {
+		m.discardedOnStop++
+	} else {
+		m.events[e]++
+	}
+	m.mu.Unlock()
+
+	if m.sink != nil {
+		m.sink.Event(e, discarded)
+	}
+}
+
+func (m *metrics) error(kind ErrorKind) {
+	m.mu.Lock()
+	m.errors[kind]++
+	m.mu.Unlock()
+
+	if m.sink != nil {
+		m.sink.Error(kind)
+	}
+}
+
+func (m *metrics) scan(start time.Time, duration time.Duration) {
+	m.mu.Lock()
+	m.lastScan = start
+	m.scanDuration = duration
+	m.mu.Unlock()
+
+	if m.sink != nil {
+		m.sink.Scan(duration)
+	}
+}
+
+func (m *metrics) skippedDir(dir string) {
+	m.mu.Lock()
+	m.skippedDirs++
+	m.mu.Unlock()
+
+	if m.sink != nil {
+		m.sink.SkippedDir(dir)
+	}
+}
+
+func (m *metrics) reconcileFixes(n int) {
+	if m.sink != nil && n > 0 {
+		m.sink.ReconcileFixes(n)
+	}
+}
+
+func (m *metrics) watches(n int) {
+	if m.sink != nil {
+		m.sink.Watches(n)
+	}
+}
+
+// fill copies the collected metrics to the stats.
+func (m *metrics) fill(stats *WatchStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats.Events = make(map[Event]int64, len(m.events))
+	for e, n := range m.events {
+		stats.Events[e] = n
+	}
+
+	stats.Errors = make(map[ErrorKind]int64, len(m.errors))
+	for kind, n := range m.errors {
+		stats.Errors[kind] = n
+	}
+
+	stats.DiscardedOnStop = m.discardedOnStop
+	stats.ScanDuration = m.scanDuration
+	stats.LastScan = m.lastScan
+	stats.SkippedDirs = m.skippedDirs
+}
+
+// ExpvarMetrics a MetricsSink publishing the metrics as an expvar map, e.g.
+//
+//	{"events.Create": 3, "discarded_on_stop": 0, "errors.permission": 1, "scans": 10,
+//	 "scan_duration_ns": 1200, "skipped_dirs": 0, "reconcile_fixes": 2, "watches": 5}
+type ExpvarMetrics struct {
+	m *expvar.Map
+}
+
+// NewExpvarMetrics publishes the metrics as the expvar map of name,
+// the existing map is reused if name is published already.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	if m, ok := expvar.Get(name).(*expvar.Map); ok {
+		return &ExpvarMetrics{m: m}
+	}
+
+	return &ExpvarMetrics{m: expvar.NewMap(name)}
+}
+
+// Map returns the published expvar map.
+func (e *ExpvarMetrics) Map() *expvar.Map {
+	return e.m
+}
+
+func (e *ExpvarMetrics) Event(event Event, discarded bool) {
+	if discarded {
+		e.m.Add("discarded_on_stop", 1)
+
+		return
+	}
+
+	e.m.Add("events."+event.String(), 1)
+}
+
+func (e *ExpvarMetrics) Error(kind ErrorKind) {
+	e.m.Add("errors."+string(kind), 1)
+}
+
+func (e *ExpvarMetrics) Scan(duration time.Duration) {
+	e.m.Add("scans", 1)
+	e.set("scan_duration_ns", int64(duration))
+}
+
+func (e *ExpvarMetrics) SkippedDir(string) {
+	e.m.Add("skipped_dirs", 1)
+}
+
+func (e *ExpvarMetrics) ReconcileFixes(n int) {
+	e.m.Add("reconcile_fixes", int64(n))
+}
+
+func (e *ExpvarMetrics) Watches(n int) {
+	e.set("watches", int64(n))
+}
+
+func (e *ExpvarMetrics) set(key string, value int64) {
+	v := new(expvar.Int)
+	v.Set(value)
+	e.m.Set(key, v)
+}
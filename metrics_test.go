@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	sink := fwatch.NewExpvarMetrics("fwatch_test_metrics")
+	h := fwatchtest.New(t, fwatch.WatchMethodFS, fwatch.WithMetricsSink(sink))
+
+	h.MkdirAll("/logs/sub")
+	h.MkdirAll("/big/many")
+
+	for i := range 129 {
+		h.WriteFile(fmt.Sprintf("/big/many/%d.log", i), []byte("x"))
+	}
+
+	h.Watch("/logs", true, func(string) bool { return true })
+	h.Watch("/big", true, func(string) bool { return true })
+
+	h.Advance(time.Second / 2)
+	h.WriteFile("/logs/a.log", []byte("a"))
+	h.WriteFile("/logs/b.log", []byte("b"))
+	h.Advance(time.Second / 2)
+	h.ExpectEvents(
+		fwatchtest.Event(fwatch.Create, "/logs/a.log"),
+		fwatchtest.Event(fwatch.Create, "/logs/b.log"),
+	)
+
+	stats := h.Watcher.Stats()
+
+	if stats.Events[fwatch.Create] != 2 || stats.DiscardedOnStop != 0 {
+		t.Errorf("unexpected event counts: %v, discarded %d", stats.Events, stats.DiscardedOnStop)
+	}
+
+	if stats.Errors[fwatch.ErrorKindTooManyFiles] != 1 || stats.SkippedDirs != 1 {
+		t.Errorf("unexpected error counts: %v, skipped dirs %d", stats.Errors, stats.SkippedDirs)
+	}
+
+	if !stats.LastScan.Equal(h.Clock.Now()) {
+		t.Errorf("unexpected last scan: %v", stats.LastScan)
+	}
+
+	// the root dirs and the sub dir added by the check.
+	if stats.Watches != 3 {
+		t.Errorf("expected 3 watches, got %d", stats.Watches)
+	}
+
+	for key, want := range map[string]string{
+		"events.Create":         "2",
+		"errors.too_many_files": "1",
+		"skipped_dirs":          "1",
+		"scans":                 "1",
+		"watches":               "3",
+	} {
+		if v := sink.Map().Get(key); v == nil || v.String() != want {
+			t.Errorf("expvar %s = %v, want %s", key, v, want)
+		}
+	}
+}
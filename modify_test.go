@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestModifyEvents(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs")
+		h.Watch("/logs", false, func(string) bool { return true })
+		h.Advance(time.Second / 2)
+
+		h.WriteFile("/logs/a.log", []byte("a"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/a.log"))
+
+		h.AppendFile("/logs/a.log", []byte("b"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Modify, "/logs/a.log"))
+
+		// rate limited, notified after the interval.
+		h.AppendFile("/logs/a.log", []byte("c"))
+		h.Advance(time.Second)
+		h.ExpectNoEvents()
+
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Modify, "/logs/a.log"))
+
+		h.Advance(3 * time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Inactive, "/logs/a.log"))
+
+		// a write to an inactive file makes it active.
+		h.AppendFile("/logs/a.log", []byte("d"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Active, "/logs/a.log"))
+	},
+		fwatch.WithInactiveDuration(3*time.Second),
+		fwatch.WithSilenceDuration(10*time.Second),
+		fwatch.WithModifyEvents(2*time.Second),
+	)
+}
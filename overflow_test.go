@@ -0,0 +1,55 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestOverflowRescan(t *testing.T) {
+	t.Parallel()
+
+	// the backend drops all notifications, the changes are found by the overflow rescan only.
+	backend := &manualBackend{}
+	h := fwatchtest.New(t, fwatch.WatchMethodFS, fwatch.WithBackend(backend))
+
+	h.MkdirAll("/logs")
+	h.Watch("/logs", true, func(string) bool { return true })
+	h.Advance(time.Second + time.Second/2)
+	h.ExpectNoEvents()
+
+	h.WriteFile("/logs/a.log", []byte("a"))
+	h.MkdirAll("/logs/sub")
+	h.Advance(time.Second)
+	h.ExpectNoEvents()
+
+	backend.sink.HandleError(fwatch.ErrEventOverflow)
+	h.ExpectEvents(
+		fwatchtest.Event(fwatch.Overflow, "/logs"),
+		fwatchtest.Event(fwatch.Create, "/logs/a.log"),
+		fwatchtest.Event(fwatch.DirCreate, "/logs/sub"),
+	)
+
+	if fixes := h.Watcher.Stats().ReconcileFixes; fixes != 2 {
+		t.Errorf("expected 2 reconcile fixes, got %d", fixes)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+// writeManyFiles writes more files than the default dir file count limit, including keep.log.
+func writeManyFiles(h *fwatchtest.Harness, dir string) {
+	for i := range 128 {
+		h.WriteFile(fmt.Sprintf("%s/%d.log", dir, i), []byte("x"))
+	}
+
+	h.WriteFile(dir+"/keep.log", []byte("keep"))
+}
+
+func TestDirRetry(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs/big")
+		writeManyFiles(h, "/logs/big")
+		h.Watch("/logs", true, func(name string) bool { return name == "keep.log" })
+
+		// the first retry fails, and the next is after the doubled interval.
+		h.Advance(2 * time.Second)
+		h.ExpectNoEvents()
+
+		h.Remove("/logs/big/0.log")
+		h.Advance(3 * time.Second)
+		h.ExpectNoEvents()
+
+		h.Advance(time.Second)
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.DirResumed, "/logs/big"),
+			fwatchtest.Event(fwatch.Create, "/logs/big/keep.log"),
+		)
+
+		if stats := h.Watcher.Stats(); stats.DroppedDirs != 0 || stats.Dirs != 2 {
+			t.Errorf("unexpected stats of resumed dir: %+v", stats)
+		}
+	},
+		fwatch.WithSilenceDuration(time.Hour),
+		fwatch.WithDirRetry(fwatch.RetryPolicy{
+			InitialInterval: 2 * time.Second,
+			MaxInterval:     time.Minute,
+		}),
+	)
+}
+
+func TestDirRetryAbandon(t *testing.T) {
+	t.Parallel()
+
+	h := fwatchtest.New(t, fwatch.WatchMethodTimer, fwatch.WithDirRetry(fwatch.RetryPolicy{
+		InitialInterval: time.Second,
+		MaxInterval:     time.Second,
+		MaxAttempts:     2,
+	}))
+
+	h.MkdirAll("/logs/big")
+	writeManyFiles(h, "/logs/big")
+	h.Watch("/logs", true, func(name string) bool { return name == "keep.log" })
+
+	h.Advance(time.Second)
+	h.ExpectNoEvents()
+
+	h.Advance(time.Second)
+	h.ExpectEvents(fwatchtest.Event(fwatch.DirAbandoned, "/logs/big"))
+
+	h.Advance(5 * time.Second)
+	h.ExpectNoEvents()
+
+	if e := h.Watcher.Explain("/logs/big/keep.log"); e.Reason != fwatch.ReasonDirTooManyFiles {
+		t.Errorf("unexpected explanation of abandoned dir: %s", e)
+	}
+
+	if stats := h.Watcher.Stats(); stats.DroppedDirs != 1 || stats.Errors[fwatch.ErrorKindTooManyFiles] != 2 {
+		t.Errorf("unexpected stats of abandoned dir: %+v", stats)
+	}
+
+	for _, policy := range []fwatch.RetryPolicy{
+		{},
+		{InitialInterval: time.Second},
+		{InitialInterval: time.Second, MaxInterval: time.Second, MaxAttempts: -1},
+	} {
+		if _, err := fwatch.New(fwatch.WithDirRetry(policy)); err == nil {
+			t.Errorf("expected error for invalid retry policy %+v", policy)
+		}
+	}
+}
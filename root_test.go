@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestPersistentRoots(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs/sub")
+		h.WriteFile("/logs/a.log", []byte("a"))
+		h.Watch("/logs", true, func(string) bool { return true })
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/a.log"))
+
+		h.RemoveAll("/logs")
+		h.Advance(time.Second)
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.Remove, "/logs/a.log"),
+			fwatchtest.Event(fwatch.DirRemove, "/logs/sub"),
+			fwatchtest.Event(fwatch.DirRemove, "/logs"),
+		)
+
+		if stats := h.Watcher.Stats(); stats.Dirs != 0 || stats.PendingRoots != 1 {
+			t.Errorf("unexpected stats of removed root: %+v", stats)
+		}
+
+		if e := h.Watcher.Explain("/logs/sub/b.log"); e.Reason != fwatch.ReasonRootPending || e.Root != "/logs" {
+			t.Errorf("unexpected explanation of file under removed root: %s", e)
+		}
+
+		// a file in a recreated root is not missed.
+		h.MkdirAll("/staging/sub")
+		h.WriteFile("/staging/sub/b.log", []byte("b"))
+		h.Rename("/staging", "/logs")
+		h.Advance(time.Second)
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.RootRestored, "/logs"),
+			fwatchtest.Event(fwatch.DirCreate, "/logs/sub"),
+			fwatchtest.Event(fwatch.Create, "/logs/sub/b.log"),
+		)
+
+		h.Advance(time.Second / 2)
+		h.WriteFile("/logs/c.log", []byte("c"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/c.log"))
+
+		if stats := h.Watcher.Stats(); stats.Dirs != 2 || stats.PendingRoots != 0 {
+			t.Errorf("unexpected stats of restored root: %+v", stats)
+		}
+	},
+		fwatch.WithPersistentRoots(true),
+		fwatch.WithSilenceDuration(10*time.Second),
+	)
+}
+
+func TestWatchDirPending(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		matcher := func(string) bool { return true }
+
+		if err := h.Watcher.WatchDir("/data/logs", true, matcher); !os.IsNotExist(err) {
+			t.Fatalf("expected not exist error, got %v", err)
+		}
+
+		if err := h.Watcher.WatchDir("/data/logs", true, matcher, fwatch.WatchDirPending()); err != nil {
+			t.Fatal(err)
+		}
+
+		h.MkdirAll("/data")
+		h.Advance(time.Second)
+		h.ExpectNoEvents()
+
+		// the directory is scanned as it was watched when created.
+		h.MkdirAll("/staging/sub")
+		h.WriteFile("/staging/sub/a.log", []byte("a"))
+		h.Rename("/staging", "/data/logs")
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Exists, "/data/logs/sub/a.log"))
+
+		h.Advance(time.Second / 2)
+		h.WriteFile("/data/logs/b.log", []byte("b"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/data/logs/b.log"))
+
+		if stats := h.Watcher.Stats(); stats.Dirs != 2 || stats.PendingRoots != 0 {
+			t.Errorf("unexpected stats of created root: %+v", stats)
+		}
+	},
+		fwatch.WithInitialScan(fwatch.InitialScanExists),
+	)
+}
+
+func TestWatchDirPendingNoTick(t *testing.T) {
+	t.Parallel()
+
+	// the checks are a minute apart, the root is attached by the events of its ancestors.
+	h := fwatchtest.New(t, fwatch.WatchMethodFS,
+		fwatch.WithInactiveDuration(3*time.Minute),
+		fwatch.WithPersistentRoots(true),
+	)
+
+	matcher := func(string) bool { return true }
+
+	if err := h.Watcher.WatchDir("/data/logs/app", true, matcher, fwatch.WatchDirPending()); err != nil {
+		t.Fatal(err)
+	}
+
+	h.MkdirAll("/data/logs")
+	h.Advance(time.Second)
+	h.ExpectNoEvents()
+
+	h.MkdirAll("/data/logs/app")
+	h.Advance(time.Second)
+	h.WriteFile("/data/logs/app/a.log", []byte("a"))
+	h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/data/logs/app/a.log"))
+
+	if stats := h.Watcher.Stats(); stats.Dirs != 1 || stats.PendingRoots != 0 {
+		t.Errorf("unexpected stats of created root: %+v", stats)
+	}
+
+	// the root removed and recreated is attached again.
+	h.RemoveAll("/data/logs/app")
+	h.ExpectEvents(
+		fwatchtest.Event(fwatch.Remove, "/data/logs/app/a.log"),
+		fwatchtest.Event(fwatch.DirRemove, "/data/logs/app"),
+	)
+
+	h.MkdirAll("/data/logs/app")
+	h.Advance(time.Second)
+	h.WriteFile("/data/logs/app/b.log", []byte("b"))
+	h.ExpectEvents(
+		fwatchtest.Event(fwatch.RootRestored, "/data/logs/app"),
+		fwatchtest.Event(fwatch.Create, "/data/logs/app/b.log"),
+	)
+}
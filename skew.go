@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"path/filepath"
+	"time"
+)
+
+const (
+	// SkewProbeName the name of the file written in root directories to measure the clock skew,
+	// it's never notified.
+	SkewProbeName = ".fwatch-skew-probe"
+
+	probeFilePerm = 0o600
+
+	// skews less than the mod time granularity of common file systems are ignored.
+	minimalSkew = time.Second
+)
+
+// probeSkews measures the clock skews of all root directories when the probe interval reached.
+func (fw *FileWatcher) probeSkews(now time.Time) {
+	if fw.skewProbeInterval == 0 || now.Before(fw.nextSkewProbe) {
+		return
+	}
+
+	fw.nextSkewProbe = now.Add(fw.skewProbeInterval)
+
+	for dir, stat := range fw.dirs {
+		if stat.root == dir {
+			fw.probeSkew(dir)
+		}
+	}
+}
+
+// probeSkew measures the clock skew of the file system of a root directory,
+// by comparing the mod time of a probe file written in it with the current time.
+// A positive skew means the file system clock is ahead.
+func (fw *FileWatcher) probeSkew(root string) {
+	if fw.skewProbeInterval == 0 {
+		return
+	}
+
+	wfs, ok := fw.fs.(WritableFS)
+	if !ok {
+		fw.logger.Debug("file system not writable for skew probe", "root", root)
+
+		return
+	}
+
+	probe := filepath.Join(root, SkewProbeName)
+
+	now := fw.clock.Now()
+	if err := wfs.WriteFile(probe, nil); err != nil {
+		fw.logger.Debug("write skew probe error", "root", root, "err", err)
+
+		return
+	}
+
+	defer func() {
+		if err := wfs.Remove(probe); err != nil {
+			fw.logger.Debug("remove skew probe error", "root", root, "err", err)
+		}
+	}()
+
+	info, err := fw.fs.Stat(probe)
+	if err != nil {
+		fw.logger.Debug("stat skew probe error", "root", root, "err", err)
+
+		return
+	}
+
+	skew := info.ModTime().Sub(now)
+	if skew.Abs() < minimalSkew {
+		skew = 0
+	}
+
+	if old, probed := fw.skews[root]; !probed || old != skew {
+		fw.logger.Info("clock skew", "root", root, "skew", skew)
+	}
+
+	fw.skews[root] = skew
+}
+
+// skewDeadline shifts a deadline by the clock skew of a root directory,
+// to compare it with the mod times of the files under the root.
+func (fw *FileWatcher) skewDeadline(root string, deadline time.Time) time.Time {
+	return deadline.Add(fw.skews[root])
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+// skewedClock the clock of a file system server ahead of the local clock by skew.
+type skewedClock struct {
+	*fwatch.FakeClock
+	skew time.Duration
+}
+
+func (c skewedClock) Now() time.Time {
+	return c.FakeClock.Now().Add(c.skew)
+}
+
+func TestSkewProbe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		skew time.Duration
+	}{
+		{"ahead", 10 * time.Minute},
+		{"behind", -10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+				h.FS.SetClock(skewedClock{FakeClock: h.Clock, skew: tt.skew})
+
+				h.MkdirAll("/nfs")
+				h.Watch("/nfs", false, func(string) bool { return true })
+
+				if skew := h.Watcher.Stats().ClockSkews["/nfs"]; skew != tt.skew {
+					t.Fatalf("expected skew %s, got %s", tt.skew, skew)
+				}
+
+				// the lifecycle follows the file system clock.
+				h.Advance(time.Second / 2)
+				h.WriteFile("/nfs/a.log", []byte("a"))
+				h.Advance(time.Second)
+				h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/nfs/a.log"))
+
+				h.Advance(2 * time.Second)
+				h.ExpectEvents(fwatchtest.Event(fwatch.Inactive, "/nfs/a.log"))
+
+				h.Advance(2 * time.Second)
+				h.ExpectEvents(fwatchtest.Event(fwatch.Silence, "/nfs/a.log"))
+			}, fwatch.WithSkewProbe(time.Minute))
+		})
+	}
+
+	if _, err := fwatch.New(fwatch.WithSkewProbe(time.Millisecond)); err == nil {
+		t.Fatal("expected error for too small skew probe interval")
+	}
+}
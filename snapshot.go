@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FileSnapshot a copy of the watch state of a file.
+type FileSnapshot struct {
+	Path    string
+	Root    string
+	State   State
+	ModTime time.Time
+
+	// Pending the file is added since the last check, and not checked yet.
+	Pending bool
+}
+
+// DirSnapshot a copy of the watch state of a directory.
+type DirSnapshot struct {
+	Path       string
+	Root       string
+	IncludeSub bool
+	ModTime    time.Time
+
+	// Pending the directory is added since the last check, and not checked yet.
+	Pending bool
+}
+
+// Files returns snapshots of the watched files sorted by path, including the pending ones.
+func (fw *FileWatcher) Files() []FileSnapshot {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	files := make([]FileSnapshot, 0, len(fw.files)+len(fw.newFiles))
+
+	for path, stat := range fw.files {
+		files = append(files, fileSnapshot(path, stat, false))
+	}
+
+	for path, stat := range fw.newFiles {
+		files = append(files, fileSnapshot(path, stat, true))
+	}
+
+	slices.SortFunc(files, func(a, b FileSnapshot) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return files
+}
+
+// Dirs returns snapshots of the watched directories sorted by path, including the pending ones.
+func (fw *FileWatcher) Dirs() []DirSnapshot {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	dirs := make([]DirSnapshot, 0, len(fw.dirs)+len(fw.newDirs))
+
+	for path, stat := range fw.dirs {
+		dirs = append(dirs, dirSnapshot(path, stat, false))
+	}
+
+	for path, stat := range fw.newDirs {
+		dirs = append(dirs, dirSnapshot(path, stat, true))
+	}
+
+	slices.SortFunc(dirs, func(a, b DirSnapshot) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return dirs
+}
+
+// FileState returns the snapshot of a watched file, ok is false if the file is not watched.
+func (fw *FileWatcher) FileState(path string) (snapshot FileSnapshot, ok bool) {
+	path = filepath.Clean(path)
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if stat, found := fw.files[path]; found {
+		return fileSnapshot(path, stat, false), true
+	}
+
+	if stat, found := fw.newFiles[path]; found {
+		return fileSnapshot(path, stat, true), true
+	}
+
+	return FileSnapshot{Path: path, State: StateUntracked}, false
+}
+
+func fileSnapshot(path string, stat *FileStat, pending bool) FileSnapshot {
+	state := StateInactive
+	if stat.active {
+		state = StateActive
+	}
+
+	return FileSnapshot{
+		Path:    path,
+		Root:    stat.root,
+		State:   state,
+		ModTime: stat.modTime,
+		Pending: pending,
+	}
+}
+
+func dirSnapshot(path string, stat *DirStat, pending bool) DirSnapshot {
+	return DirSnapshot{
+		Path:       path,
+		Root:       stat.root,
+		IncludeSub: stat.includeSub,
+		ModTime:    stat.modTime,
+		Pending:    pending,
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestSnapshots(t *testing.T) {
+	t.Parallel()
+
+	h := fwatchtest.New(t, fwatch.WatchMethodTimer)
+
+	h.MkdirAll("/logs/sub")
+	h.WriteFile("/logs/a.log", []byte("a"))
+	h.WriteFile("/logs/sub/b.log", []byte("b"))
+	h.Watch("/logs", true, func(string) bool { return true })
+
+	created := h.Clock.Now()
+
+	files := h.Watcher.Files()
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %+v", files)
+	}
+
+	want := fwatch.FileSnapshot{
+		Path:    "/logs/a.log",
+		Root:    "/logs",
+		State:   fwatch.StateActive,
+		ModTime: created,
+		Pending: true,
+	}
+	if files[0] != want {
+		t.Errorf("files[0] = %+v, want %+v", files[0], want)
+	}
+
+	dirs := h.Watcher.Dirs()
+	if len(dirs) != 2 || dirs[0].Path != "/logs" || dirs[0].Pending ||
+		dirs[1].Path != "/logs/sub" || !dirs[1].Pending || dirs[1].Root != "/logs" || !dirs[1].IncludeSub {
+		t.Errorf("unexpected dirs: %+v", dirs)
+	}
+
+	// the pending entries are moved to the watched ones by the check.
+	h.Advance(3 * time.Second)
+
+	state, ok := h.Watcher.FileState("/logs/sub/b.log")
+	if !ok || state.Pending || state.State != fwatch.StateInactive || state.Root != "/logs" {
+		t.Errorf("unexpected file state: %+v, %v", state, ok)
+	}
+
+	for _, path := range []string{"/logs/sub/./b.log", "/logs//sub/b.log", "/logs/x/../sub/b.log"} {
+		if state, ok = h.Watcher.FileState(path); !ok || state.Path != "/logs/sub/b.log" {
+			t.Errorf("unexpected file state of %s: %+v, %v", path, state, ok)
+		}
+	}
+
+	if state, ok = h.Watcher.FileState("/logs/none.log"); ok || state.State != fwatch.StateUntracked {
+		t.Errorf("unexpected state of not watched file: %+v, %v", state, ok)
+	}
+
+	if dirs = h.Watcher.Dirs(); dirs[1].Pending {
+		t.Errorf("expected sub dir checked: %+v", dirs[1])
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"os"
+	"strings"
+)
+
+// SpecialFile types of special files, which are not regular files nor directories.
+// Special files are not watched unless included by WithSpecialFiles.
+type SpecialFile uint8
+
+const (
+	// SpecialFIFO named pipes.
+	SpecialFIFO SpecialFile = 1 << iota
+
+	// SpecialSocket unix domain sockets.
+	SpecialSocket
+
+	// SpecialDevice block and character device nodes.
+	SpecialDevice
+)
+
+func (s SpecialFile) String() string {
+	var names []string
+
+	if s&SpecialFIFO != 0 {
+		names = append(names, "fifo")
+	}
+
+	if s&SpecialSocket != 0 {
+		names = append(names, "socket")
+	}
+
+	if s&SpecialDevice != 0 {
+		names = append(names, "device")
+	}
+
+	return strings.Join(names, "|")
+}
+
+// specialFileOf returns the special file type of a file mode, 0 if it's not a special file.
+func specialFileOf(mode os.FileMode) SpecialFile {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return SpecialFIFO
+	case mode&os.ModeSocket != 0:
+		return SpecialSocket
+	case mode&os.ModeDevice != 0:
+		return SpecialDevice
+	default:
+		return 0
+	}
+}
+
+// skipSpecial whether a file is a special file not included to watch.
+// The info must be of the symlink target, e.g. returned by unlink or Stat.
+func (fw *FileWatcher) skipSpecial(path string, info os.FileInfo, dirStat *DirStat) bool {
+	special := specialFileOf(info.Mode())
+	if special == 0 || fw.specialFiles&special != 0 {
+		return false
+	}
+
+	fw.trace("ignore special file", "root", dirStat.root, "path", path, "type", special)
+
+	return true
+}
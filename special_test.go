@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestSpecialFilesSkipped(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/run")
+		h.Watch("/run", false, func(string) bool { return true })
+		h.Advance(time.Second / 2)
+
+		h.Mknod("/run/pipe", os.ModeNamedPipe)
+		h.Mknod("/run/app.sock", os.ModeSocket)
+		h.Symlink("/run/pipe", "/run/link")
+		h.Advance(time.Second)
+		h.ExpectNoEvents()
+
+		for _, path := range []string{"/run/pipe", "/run/app.sock", "/run/link"} {
+			if e := h.Watcher.Explain(path); e.Reason != fwatch.ReasonSpecialFile {
+				t.Errorf("unexpected explanation of %s: %s", path, e)
+			}
+		}
+
+		h.WriteFile("/run/a.log", []byte("a"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/run/a.log"))
+	})
+}
+
+func TestSpecialFilesIncluded(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/run")
+		h.Watch("/run", false, func(string) bool { return true })
+		h.Advance(time.Second / 2)
+
+		h.Mknod("/run/pipe", os.ModeNamedPipe)
+		h.Mknod("/run/app.sock", os.ModeSocket)
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/run/pipe"))
+
+		// never hashed for the checksum when inactive.
+		h.Advance(2 * time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Inactive, "/run/pipe"))
+	}, fwatch.WithSpecialFiles(fwatch.SpecialFIFO), fwatch.WithChecksum(0))
+}
@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"os"
+	"time"
+)
+
+// SysStat the system dependent stat of a file. A custom FS can return a *SysStat
+// from os.FileInfo.Sys() to provide these fields.
+type SysStat struct {
+	// Inode number of the file.
+	Inode uint64
+
+	// Ctime the last time the inode of the file changed.
+	Ctime time.Time
+
+	// UID and GID the owner of the file.
+	UID int
+	GID int
+
+	// Dev the id of the device containing the file.
+	Dev uint64
+}
+
+// sysStatOf returns the system dependent stat of a file info, ok is false if not available.
+func sysStatOf(info os.FileInfo) (SysStat, bool) {
+	if stat, ok := info.Sys().(*SysStat); ok {
+		return *stat, true
+	}
+
+	return platformSysStat(info)
+}
+
+// Change describes a set of changed fields of a file.
+type Change uint32
+
+// These are the changed fields reported in Active and Modify events.
+const (
+	ChangeModTime Change = 1 << iota
+	ChangeSize
+	ChangeCtime
+	ChangeInode
+	ChangeContent
+)
+
+var changeNames = []struct {
+	change Change
+	name   string
+}{
+	{ChangeModTime, "modTime"},
+	{ChangeSize, "size"},
+	{ChangeCtime, "ctime"},
+	{ChangeInode, "inode"},
+	{ChangeContent, "content"},
+}
+
+// Has whether the change set contains c.
+func (c Change) Has(o Change) bool {
+	return c&o == o
+}
+
+// String change desc, e.g. size|ctime.
+func (c Change) String() string {
+	desc := ""
+
+	for _, n := range changeNames {
+		if !c.Has(n.change) {
+			continue
+		}
+
+		if desc != "" {
+			desc += "|"
+		}
+
+		desc += n.name
+	}
+
+	return desc
+}
+
+// FileAttr the attributes of a file.
+type FileAttr struct {
+	Mode os.FileMode
+
+	// UID and GID the owner of the file, -1 if not available on the platform.
+	UID int
+	GID int
+}
+
+// AttrChange the old and new attributes of a file in an Attrib event.
+type AttrChange struct {
+	Old FileAttr
+	New FileAttr
+}
+
+func fileAttrOf(info os.FileInfo) FileAttr {
+	attr := FileAttr{Mode: info.Mode(), UID: -1, GID: -1}
+
+	if stat, ok := sysStatOf(info); ok {
+		attr.UID = stat.UID
+		attr.GID = stat.GID
+	}
+
+	return attr
+}
+
+// fingerprint a cheap identity of a file, to catch changes keeping the same mod time.
+type fingerprint struct {
+	size  int64
+	ctime time.Time
+	inode uint64
+}
+
+func fingerprintOf(info os.FileInfo) fingerprint {
+	fp := fingerprint{size: info.Size()}
+
+	if stat, ok := sysStatOf(info); ok {
+		fp.ctime = stat.Ctime
+		fp.inode = stat.Inode
+	}
+
+	return fp
+}
+
+// diff returns the changed fields from fp to other.
+func (fp fingerprint) diff(other fingerprint) Change {
+	var c Change
+
+	if fp.size != other.size {
+		c |= ChangeSize
+	}
+
+	if !fp.ctime.Equal(other.ctime) {
+		c |= ChangeCtime
+	}
+
+	if fp.inode != other.inode {
+		c |= ChangeInode
+	}
+
+	return c
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func platformSysStat(info os.FileInfo) (SysStat, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return SysStat{}, false
+	}
+
+	return SysStat{
+		Inode: stat.Ino,
+		Ctime: time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec)), //nolint:unconvert // int32 on some archs
+		UID:   int(stat.Uid),
+		GID:   int(stat.Gid),
+		Dev:   uint64(stat.Dev), //nolint:unconvert // uint32 on some archs
+	}, true
+}
@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func expectActiveChange(t *testing.T, h *fwatchtest.Harness, name string, want fwatch.Change) {
+	t.Helper()
+
+	events := h.Events()
+	if len(events) != 1 || events[0].Event != fwatch.Active || events[0].Name != name {
+		t.Fatalf("expected Active of %s, got %v", name, events)
+	}
+
+	if events[0].Change != want {
+		t.Errorf("expected change %v, got %v", want, events[0].Change)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs")
+		h.Watch("/logs", false, func(name string) bool { return strings.HasSuffix(name, ".log") })
+		h.Advance(time.Second / 2)
+
+		h.WriteFile("/logs/a.log", []byte("a"))
+		h.Advance(3 * time.Second)
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.Create, "/logs/a.log"),
+			fwatchtest.Event(fwatch.Inactive, "/logs/a.log"),
+		)
+
+		info, _ := h.FS.Stat("/logs/a.log")
+
+		// copy keeping the mod time, like `cp -p`.
+		h.WriteFile("/logs/a.log", []byte("copied"))
+		h.Chtimes("/logs/a.log", info.ModTime())
+		h.Advance(time.Second)
+		expectActiveChange(t, h, "/logs/a.log", fwatch.ChangeSize|fwatch.ChangeCtime)
+
+		// the kept mod time is already over the inactive deadline.
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Inactive, "/logs/a.log"))
+
+		// swap by rename with an older mod time.
+		h.WriteFile("/logs/a.tmp", []byte("swaped"))
+		h.Chtimes("/logs/a.tmp", info.ModTime())
+		h.Rename("/logs/a.tmp", "/logs/a.log")
+		h.Advance(time.Second)
+		expectActiveChange(t, h, "/logs/a.log", fwatch.ChangeCtime|fwatch.ChangeInode)
+	}, fwatch.WithFingerprint(true))
+}
+
+func TestChangeString(t *testing.T) {
+	t.Parallel()
+
+	if got := (fwatch.ChangeSize | fwatch.ChangeInode).String(); got != "size|inode" {
+		t.Errorf("Change.String() = %q, want %q", got, "size|inode")
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+// State the lifecycle state of a watched file, changed by lifecycle events:
+//
+//	       Create/Exists         Inactive
+//	Untracked ───────► Active ────────────► Inactive
+//	    ▲                 ▲                  │    │
+//	    │                 └───── Active ─────┘    │
+//	    │                                         │
+//	    └──────────────── Silence ────────────────┘
+//
+// Remove moves a file in any tracked state back to Untracked.
+type State uint8
+
+// These are the lifecycle states of a file.
+const (
+	// StateUntracked the file is not watched, e.g. not created yet, removed or silenced.
+	StateUntracked State = iota
+
+	// StateActive the file is updated in the inactive duration.
+	StateActive
+
+	// StateInactive the file is not updated in the inactive duration.
+	StateInactive
+)
+
+// String state desc.
+func (s State) String() string {
+	switch s {
+	case StateUntracked:
+		return "Untracked"
+	case StateActive:
+		return "Active"
+	case StateInactive:
+		return "Inactive"
+	}
+
+	return ""
+}
+
+// Transition returns the state after a lifecycle event, ok is false if the event
+// is not a lifecycle event or not expected in the state.
+func (s State) Transition(e Event) (next State, ok bool) {
+	switch {
+	case s == StateUntracked && (e == Create || e == Exists):
+		return StateActive, true
+	case s == StateActive && e == Inactive:
+		return StateInactive, true
+	case s == StateInactive && e == Active:
+		return StateActive, true
+	case s == StateInactive && e == Silence:
+		return StateUntracked, true
+	case s != StateUntracked && e == Remove:
+		return StateUntracked, true
+	}
+
+	return s, false
+}
@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"testing"
+
+	"github.com/vogo/fwatch"
+)
+
+func TestStateTransition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from  fwatch.State
+		event fwatch.Event
+		to    fwatch.State
+		ok    bool
+	}{
+		{fwatch.StateUntracked, fwatch.Create, fwatch.StateActive, true},
+		{fwatch.StateUntracked, fwatch.Exists, fwatch.StateActive, true},
+		{fwatch.StateActive, fwatch.Inactive, fwatch.StateInactive, true},
+		{fwatch.StateInactive, fwatch.Active, fwatch.StateActive, true},
+		{fwatch.StateInactive, fwatch.Silence, fwatch.StateUntracked, true},
+		{fwatch.StateActive, fwatch.Remove, fwatch.StateUntracked, true},
+		{fwatch.StateInactive, fwatch.Remove, fwatch.StateUntracked, true},
+		{fwatch.StateActive, fwatch.Silence, fwatch.StateActive, false},
+		{fwatch.StateActive, fwatch.Modify, fwatch.StateActive, false},
+		{fwatch.StateUntracked, fwatch.Remove, fwatch.StateUntracked, false},
+	}
+
+	for _, tt := range tests {
+		to, ok := tt.from.Transition(tt.event)
+		if to != tt.to || ok != tt.ok {
+			t.Errorf("%v.Transition(%v) = %v, %v, want %v, %v", tt.from, tt.event, to, ok, tt.to, tt.ok)
+		}
+	}
+}
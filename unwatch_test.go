@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestUnwatchDir(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs/sub")
+		h.WriteFile("/logs/a.log", []byte("a"))
+		h.WriteFile("/logs/sub/b.log", []byte("b"))
+		h.Watch("/logs", true, func(string) bool { return true })
+		h.Advance(time.Second)
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.Create, "/logs/a.log"),
+			fwatchtest.Event(fwatch.Create, "/logs/sub/b.log"),
+		)
+
+		if err := h.Watcher.UnwatchDir("/logs/"); err != nil {
+			t.Fatal(err)
+		}
+
+		h.ExpectEvents(
+			fwatchtest.Event(fwatch.Unwatched, "/logs/a.log"),
+			fwatchtest.Event(fwatch.Unwatched, "/logs/sub/b.log"),
+		)
+
+		if stats := h.Watcher.Stats(); stats.Dirs != 0 || stats.Files != 0 || stats.Watches != 0 {
+			t.Errorf("unexpected stats after unwatch: %+v", stats)
+		}
+
+		// changes under the unwatched directory are not notified.
+		h.AppendFile("/logs/sub/b.log", []byte("b"))
+		h.WriteFile("/logs/sub/c.log", []byte("c"))
+		h.Advance(5 * time.Second)
+		h.ExpectNoEvents()
+
+		if err := h.Watcher.UnwatchDir("/logs/sub"); !errors.Is(err, fwatch.ErrNotWatched) {
+			t.Errorf("expected ErrNotWatched, got %v", err)
+		}
+	},
+		fwatch.WithUnwatchedEvents(true),
+	)
+}
+
+func TestUnwatchSubDir(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs/sub")
+		h.Watch("/logs", true, func(string) bool { return true })
+		h.Advance(time.Second + time.Second/2)
+
+		if err := h.Watcher.UnwatchDir("/logs/sub"); err != nil {
+			t.Fatal(err)
+		}
+
+		// the parent changes, but the unwatched sub directory is not watched again.
+		h.WriteFile("/logs/a.log", []byte("a"))
+		h.WriteFile("/logs/sub/b.log", []byte("b"))
+		h.Advance(2 * time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/a.log"))
+
+		if e := h.Watcher.Explain("/logs/sub/b.log"); e.Reason != fwatch.ReasonDirUnwatched || e.Dir != "/logs/sub" {
+			t.Errorf("unexpected explanation: %s", e)
+		}
+
+		// watching the root again watches the sub directory.
+		h.Watch("/logs", true, func(string) bool { return true })
+
+		if e := h.Watcher.Explain("/logs/sub/b.log"); e.Reason != fwatch.ReasonWatched {
+			t.Errorf("unexpected explanation after watching again: %s", e)
+		}
+	}, fwatch.WithSilenceDuration(time.Hour))
+}
+
+func TestUnwatchDirPaths(t *testing.T) {
+	t.Parallel()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tempDir := t.TempDir()
+
+	rel, err := filepath.Rel(wd, tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{tempDir + "/", "./" + rel, rel + "/", tempDir + "/sub/.."} {
+		w, err := fwatch.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = w.WatchDir(dir, true, func(string) bool { return true }); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = w.UnwatchDir(dir); err != nil {
+			t.Errorf("unwatch %s: %v", dir, err)
+		}
+
+		if stats := w.Stats(); stats.Dirs != 0 {
+			t.Errorf("unexpected dirs after unwatch %s: %d", dir, stats.Dirs)
+		}
+
+		_ = w.Stop()
+	}
+}
@@ -20,6 +20,7 @@ package fwatch
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func IsDir(name string) bool {
@@ -28,7 +29,9 @@ func IsDir(name string) bool {
 	return err == nil && stat != nil && stat.IsDir()
 }
 
-func unlink(path string, info os.FileInfo) (unlinkPath string, dir bool, fileInfo os.FileInfo, fileErr error) {
+// unlink resolves the symbolic links of path, and returns the real path with the info of the target,
+// so the type of a link to a directory or a special file is the one of the target.
+func unlink(fsys FS, path string, info os.FileInfo) (unlinkPath string, dir bool, fileInfo os.FileInfo, fileErr error) {
 	if info.IsDir() {
 		return path, true, info, nil
 	}
@@ -36,12 +39,12 @@ func unlink(path string, info os.FileInfo) (unlinkPath string, dir bool, fileInf
 	var err error
 
 	for info.Mode()&os.ModeSymlink != 0 {
-		path, err = filepath.EvalSymlinks(path)
+		path, err = fsys.EvalSymlinks(path)
 		if err != nil {
 			return "", false, nil, err
 		}
 
-		info, err = os.Lstat(path)
+		info, err = fsys.Lstat(path)
 		if err != nil {
 			return "", false, nil, err
 		}
@@ -49,3 +52,17 @@ func unlink(path string, info os.FileInfo) (unlinkPath string, dir bool, fileInf
 
 	return path, info.IsDir(), info, nil
 }
+
+// isSubPath whether path is dir or under it.
+func isSubPath(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// deleteSubPaths deletes the keys of m which are dir or under it.
+func deleteSubPaths[V any](m map[string]V, dir string) {
+	for p := range m {
+		if isSubPath(dir, p) {
+			delete(m, p)
+		}
+	}
+}
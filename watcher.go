@@ -33,22 +33,26 @@ func calcInterval(deadline time.Duration) time.Duration {
 
 // start file watcher.
 func (fw *FileWatcher) start() error {
-	ticker := time.NewTicker(calcInterval(fw.inactiveDuration))
-
-	if fw.method == WatchMethodFS {
-		if err := fw.startFsDirWatcher(); err != nil {
-			return err
-		}
+	if err := fw.backend.Start(backendSink{fw: fw}); err != nil {
+		return err
 	}
 
+	ticker := fw.clock.NewTicker(calcInterval(fw.inactiveDuration))
+
 	// start ticker.
 	go func() {
+		defer ticker.Stop()
+
 		for {
 			select {
 			case <-fw.runner.C:
 				return
-			case now := <-ticker.C:
+			case now := <-ticker.C():
 				fw.timerCheck(now)
+
+				if acker, ok := ticker.(tickAcker); ok {
+					acker.ack()
+				}
 			}
 		}
 	}()
@@ -60,14 +64,31 @@ func (fw *FileWatcher) timerCheck(now time.Time) {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
+	// the scan duration is measured in real time, not by the clock.
+	start := time.Now()
+
 	inactiveDeadline := now.Add(-fw.inactiveDuration)
 	silenceDeadline := now.Add(-fw.silenceDuration)
 
+	// measure clock skews before checking files.
+	fw.probeSkews(now)
+
 	// check files.
-	fw.checkFiles(inactiveDeadline, silenceDeadline)
+	fw.checkFiles(now, inactiveDeadline, silenceDeadline)
 
 	// check dirs.
-	fw.timerDirsChecker(silenceDeadline)
+	switch {
+	case fw.backend.Polling():
+		fw.checkDirs(silenceDeadline)
+	case fw.method == WatchMethodHybrid:
+		fw.reconcileDirs(now, silenceDeadline)
+	}
+
+	// check removed roots to watch again.
+	fw.checkPendingRoots()
+
+	// retry dropped dirs.
+	fw.retryDirs(now, silenceDeadline)
 
 	// move new dirs to watch dirs map.
 	for dir, stat := range fw.newDirs {
@@ -75,7 +96,7 @@ func (fw *FileWatcher) timerCheck(now time.Time) {
 
 		delete(fw.newDirs, dir)
 
-		fw.newDirWatchInit(dir)
+		fw.backendAdd(dir)
 	}
 
 	// move new files to watch files map.
@@ -84,4 +105,7 @@ func (fw *FileWatcher) timerCheck(now time.Time) {
 
 		delete(fw.newFiles, f)
 	}
+
+	fw.metrics.scan(now, time.Since(start))
+	fw.metrics.watches(fw.watchCount())
 }
@@ -0,0 +1,159 @@
+//go:build linux
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// closeWriteWatcher watches IN_CLOSE_WRITE of files under directories using inotify,
+// which is not exposed by fsnotify.
+type closeWriteWatcher struct {
+	mu    sync.Mutex
+	fd    int
+	file  *os.File
+	dirs  map[int]string
+	paths map[string]int
+}
+
+func newCloseWriteWatcher() (*closeWriteWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	return &closeWriteWatcher{
+		fd:    fd,
+		file:  os.NewFile(uintptr(fd), "close-write-inotify"),
+		dirs:  make(map[int]string, defaultMapSize),
+		paths: make(map[string]int, defaultMapSize),
+	}, nil
+}
+
+// Add starts watching the close write events of files under dir.
+func (w *closeWriteWatcher) Add(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, syscall.IN_CLOSE_WRITE|syscall.IN_ONLYDIR)
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	w.dirs[wd] = dir
+	w.paths[dir] = wd
+
+	return nil
+}
+
+// Remove stops watching the close write events of files under dir.
+func (w *closeWriteWatcher) Remove(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wd, ok := w.paths[dir]
+	if !ok {
+		return nil
+	}
+
+	delete(w.paths, dir)
+	delete(w.dirs, wd)
+
+	if _, err := syscall.InotifyRmWatch(w.fd, uint32(wd)); err != nil {
+		return os.NewSyscallError("inotify_rm_watch", err)
+	}
+
+	return nil
+}
+
+// Close releases the inotify instance, and stops the run loop.
+func (w *closeWriteWatcher) Close() error {
+	return w.file.Close()
+}
+
+// run reads inotify events until the watcher is closed, and calls handle with the path of closed files.
+// overflow is called when the inotify queue overflowed, and close write events were lost.
+func (w *closeWriteWatcher) run(handle func(path string), overflow func(), logger *slog.Logger) {
+	var buf [syscall.SizeofInotifyEvent * 4096]byte
+
+	for {
+		n, err := w.file.Read(buf[:])
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				logger.Warn("read close write events error", "err", err)
+			}
+
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			wd := int(int32(binary.NativeEndian.Uint32(buf[offset:])))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+nameLen]
+			offset += syscall.SizeofInotifyEvent + nameLen
+
+			if mask&syscall.IN_Q_OVERFLOW != 0 {
+				logger.Warn("close write event queue overflow")
+				overflow()
+
+				continue
+			}
+
+			if mask&syscall.IN_CLOSE_WRITE == 0 || nameLen == 0 {
+				w.handleIgnored(wd, mask)
+
+				continue
+			}
+
+			w.mu.Lock()
+			dir, ok := w.dirs[wd]
+			w.mu.Unlock()
+
+			if !ok {
+				continue
+			}
+
+			handle(filepath.Join(dir, string(bytes.TrimRight(nameBytes, "\x00"))))
+		}
+	}
+}
+
+// handleIgnored forgets the watch removed by the kernel, e.g. when the directory is deleted.
+func (w *closeWriteWatcher) handleIgnored(wd int, mask uint32) {
+	if mask&syscall.IN_IGNORED == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if dir, ok := w.dirs[wd]; ok {
+		delete(w.dirs, wd)
+		delete(w.paths, dir)
+	}
+}
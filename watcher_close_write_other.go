@@ -0,0 +1,43 @@
+//go:build !linux
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"errors"
+	"log/slog"
+)
+
+var errCloseWriteUnsupported = errors.New("close write event is only supported on linux")
+
+// closeWriteWatcher is a fallback for platforms without IN_CLOSE_WRITE,
+// files are only reported Inactive after the inactive duration on these platforms.
+type closeWriteWatcher struct{}
+
+func newCloseWriteWatcher() (*closeWriteWatcher, error) {
+	return nil, errCloseWriteUnsupported
+}
+
+func (w *closeWriteWatcher) Add(string) error { return nil }
+
+func (w *closeWriteWatcher) Remove(string) error { return nil }
+
+func (w *closeWriteWatcher) Close() error { return nil }
+
+func (w *closeWriteWatcher) run(func(path string), func(), *slog.Logger) {}
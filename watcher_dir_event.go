@@ -0,0 +1,239 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+func (fw *FileWatcher) handleBackendError(err error) {
+	if errors.Is(err, ErrEventOverflow) {
+		fw.metrics.error(ErrorKindOverflow)
+		fw.handleOverflow()
+
+		return
+	}
+
+	fw.metrics.error(ErrorKindBackend)
+	fw.logger.Error("watch dir error", "err", err)
+}
+
+// handleOverflow notifies the roots that events may have been missed,
+// and rescans all directories to bring the watched files back in sync.
+func (fw *FileWatcher) handleOverflow() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	roots := make(map[string]struct{})
+
+	for _, stat := range fw.dirs {
+		if _, ok := roots[stat.root]; ok {
+			continue
+		}
+
+		roots[stat.root] = struct{}{}
+
+		fw.sendEvent(&WatchEvent{
+			Name:  stat.root,
+			Event: Overflow,
+		})
+	}
+
+	fixed := fw.rescanDirs(fw.clock.Now().Add(-fw.silenceDuration))
+	fw.reconcileFixes += int64(fixed)
+	fw.metrics.reconcileFixes(fixed)
+
+	fw.logger.Warn("event overflow, rescan roots", "roots", len(roots), "fixed", fixed)
+}
+
+// handleCloseWrite notifies a file opened for writing was closed,
+// a Create event is sent before if the file is not watched yet.
+func (fw *FileWatcher) handleCloseWrite(path string) {
+	fileInfo, err := fw.fs.Stat(path)
+	if err != nil {
+		fw.logger.Debug("stat closed file error", "path", path, "op", OpCloseWrite, "err", err)
+
+		return
+	}
+
+	if fileInfo.IsDir() {
+		return
+	}
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	dirStat, ok := fw.dirs[filepath.Dir(path)]
+	if !ok || fw.skipSpecial(path, fileInfo, dirStat) || !dirStat.matcher(path) {
+		return
+	}
+
+	fw.tryAddNewFile(path, fileInfo, dirStat, fw.clock.Now().Add(-fw.silenceDuration))
+
+	if _, ok = fw.watchedFile(path); !ok {
+		return
+	}
+
+	fw.sendEvent(&WatchEvent{
+		Name:  path,
+		Event: CloseWrite,
+	})
+}
+
+func (fw *FileWatcher) handleBackendEvent(event BackendEvent) {
+	fw.logger.Debug("dir event", "path", event.Name, "op", event.Op)
+
+	// ignore root dir events and the skew probe.
+	if event.Name == "" || event.Name == "." || filepath.Base(event.Name) == SkewProbeName {
+		return
+	}
+
+	if event.Op.Has(OpCloseWrite) {
+		fw.handleCloseWrite(event.Name)
+
+		return
+	}
+
+	if event.Op.Has(OpRemove) || event.Op.Has(OpRename) {
+		if fw.tryRemoveDir(event.Name) {
+			return
+		}
+	}
+
+	// stat file outside the lock (I/O should not hold the mutex)
+	var fileInfo os.FileInfo
+
+	if !event.Op.Has(OpRemove) && !event.Op.Has(OpRename) {
+		var err error
+
+		fileInfo, err = fw.fs.Stat(event.Name)
+		if err != nil {
+			fw.logger.Warn("stat error", "path", event.Name, "op", event.Op, "err", err)
+
+			return
+		}
+	}
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.handlePendingRootEvent(event) {
+		return
+	}
+
+	baseDir := filepath.Dir(event.Name)
+	stat, ok := fw.dirs[baseDir]
+
+	if !ok {
+		fw.logger.Warn("unexpected event", "path", event.Name, "op", event.Op)
+
+		return
+	}
+
+	if fileInfo != nil && fileInfo.IsDir() {
+		fw.handleDirsEvent(event, stat, fileInfo)
+
+		return
+	}
+
+	fw.handleFilesEvent(event, stat)
+}
+
+// tryRemoveDir removes a watched directory for a remove or rename event, including a root one.
+// A removed directory can't be stat, so it's checked in the watched directories.
+func (fw *FileWatcher) tryRemoveDir(dir string) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if _, ok := fw.watchedDir(dir); !ok {
+		return false
+	}
+
+	fw.removeDir(dir)
+
+	return true
+}
+
+func (fw *FileWatcher) handleDirsEvent(event BackendEvent, stat *DirStat, info os.FileInfo) {
+	if event.Op.Has(OpCreate) {
+		silenceDeadline := fw.clock.Now().Add(-fw.silenceDuration)
+		fw.tryAddNewSubDir(info, event.Name, stat, silenceDeadline)
+	}
+}
+
+func (fw *FileWatcher) handleFilesEvent(event BackendEvent, dirStat *DirStat) {
+	if !dirStat.matcher(event.Name) {
+		return
+	}
+
+	switch {
+	case event.Op.Has(OpRemove), event.Op.Has(OpRename):
+		fw.tryRemoveFile(event.Name, dirStat)
+	case event.Op.Has(OpCreate), event.Op.Has(OpWrite):
+		fileInfo, err := fw.fs.Stat(event.Name)
+		if err != nil {
+			fw.sendError(err)
+
+			return
+		}
+
+		if fw.skipSpecial(event.Name, fileInfo, dirStat) {
+			return
+		}
+
+		if stat, ok := fw.watchedFile(event.Name); ok {
+			fw.handleWatchedFileWrite(event.Name, fileInfo, stat)
+
+			return
+		}
+
+		silenceDeadline := fw.clock.Now().Add(-fw.silenceDuration)
+		fw.tryAddNewFile(event.Name, fileInfo, dirStat, silenceDeadline)
+	case event.Op.Has(OpChmod):
+		stat, ok := fw.watchedFile(event.Name)
+		if !ok {
+			return
+		}
+
+		fileInfo, err := fw.fs.Stat(event.Name)
+		if err != nil {
+			fw.sendError(err)
+
+			return
+		}
+
+		// the ctime changed with the attributes, not by a write.
+		if fw.checkAttr(event.Name, fileInfo, stat) {
+			stat.fingerprint.ctime = fingerprintOf(fileInfo).ctime
+		}
+	}
+}
+
+// handleWatchedFileWrite notifies Modify for a write to an active file if enabled,
+// the mod time is stored so the timer check doesn't notify the same write again.
+// A write to an inactive file is left to the timer check to make it active.
+func (fw *FileWatcher) handleWatchedFileWrite(path string, info os.FileInfo, stat *FileStat) {
+	if !fw.modifyEvents || !stat.active || !info.ModTime().After(stat.modTime) {
+		return
+	}
+
+	stat.modTime = info.ModTime()
+	fw.tryNotifyModify(path, stat, fw.clock.Now())
+}
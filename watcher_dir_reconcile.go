@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// reconcileDirs rescans all directories when the reconcile interval reached,
+// to fix the create and remove events missed by fsnotify.
+func (fw *FileWatcher) reconcileDirs(now, silenceDeadline time.Time) {
+	if now.Before(fw.nextReconcile) {
+		return
+	}
+
+	fw.nextReconcile = now.Add(fw.reconcileInterval)
+
+	fixed := fw.rescanDirs(silenceDeadline)
+	if fixed > 0 {
+		fw.logger.Info("reconcile fixed missed events", "fixed", fixed)
+	}
+
+	fw.reconcileFixes += int64(fixed)
+	fw.metrics.reconcileFixes(fixed)
+}
+
+// rescanDirs scans all directories regardless of their mod time, and diffs the entries
+// against the watched files. It returns the count of create and remove events emitted.
+func (fw *FileWatcher) rescanDirs(silenceDeadline time.Time) int {
+	fixed := 0
+	scanned := make(map[string]struct{}, len(fw.dirs))
+	exists := make(map[string]struct{})
+
+	for dir, stat := range fw.dirs {
+		entries, err := readCheckDir(fw.fs, dir, fw.dirFileCountLimit)
+		if err != nil {
+			fw.handleDirError(dir, stat, err)
+
+			continue
+		}
+
+		scanned[dir] = struct{}{}
+
+		for _, entry := range entries {
+			exists[filepath.Join(dir, entry.Name())] = struct{}{}
+		}
+
+		fixed += fw.scanDirEntries(dir, entries, stat, silenceDeadline)
+	}
+
+	// one pass over the watched files for the removed ones of the scanned directories.
+	for f, stat := range fw.files {
+		if _, ok := scanned[filepath.Dir(f)]; !ok {
+			continue
+		}
+
+		if _, ok := exists[f]; ok {
+			continue
+		}
+
+		fw.logger.Debug("rescan found removed file", "root", stat.root, "path", f)
+		fw.removeFile(f, stat)
+
+		fixed++
+	}
+
+	return fixed
+}
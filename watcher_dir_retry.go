@@ -0,0 +1,179 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RetryPolicy how to retry watching a directory dropped for an error, e.g. EACCES, EMFILE or too many files.
+type RetryPolicy struct {
+	// InitialInterval the interval before the first retry, doubled after each failed retry.
+	InitialInterval time.Duration
+
+	// MaxInterval the max interval between two retries.
+	MaxInterval time.Duration
+
+	// MaxAttempts the max count of retries before the directory is abandoned, 0 to retry forever.
+	MaxAttempts int
+}
+
+func (p RetryPolicy) validate() error {
+	if p.InitialInterval <= 0 {
+		return fmt.Errorf("retry initial interval %s is not positive", p.InitialInterval)
+	}
+
+	if p.MaxInterval < p.InitialInterval {
+		return fmt.Errorf("retry max interval %s is less than the initial interval %s", p.MaxInterval, p.InitialInterval)
+	}
+
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("retry max attempts %d is negative", p.MaxAttempts)
+	}
+
+	return nil
+}
+
+// backoff returns the interval before the next retry after failed retries.
+func (p RetryPolicy) backoff(retries int) time.Duration {
+	interval := p.InitialInterval
+
+	for range retries {
+		if interval >= p.MaxInterval/2 {
+			return p.MaxInterval
+		}
+
+		interval *= 2
+	}
+
+	return interval
+}
+
+// droppedDir a directory not watched for an error.
+type droppedDir struct {
+	err  error
+	stat *DirStat
+
+	// count of failed retries, and the time of the next retry.
+	retries   int
+	nextRetry time.Time
+
+	// whether the directory is not retried any more for the max attempts reached.
+	abandoned bool
+}
+
+// retrying whether the directory is waiting to be retried.
+func (fw *FileWatcher) retrying(dir string) bool {
+	dropped, ok := fw.droppedDirs[dir]
+
+	return ok && fw.dirRetry != nil && !dropped.abandoned
+}
+
+// dropDirForError stops watching a directory for an error, and schedules the retry if enabled.
+// The error is sent only when the directory is dropped, and when it's abandoned.
+func (fw *FileWatcher) dropDirForError(dir string, dirStat *DirStat, err error) {
+	fw.dropDir(dir)
+
+	if dropped, ok := fw.droppedDirs[dir]; ok && fw.retrying(dir) {
+		fw.retryFailed(dir, dropped, err)
+
+		return
+	}
+
+	dropped := &droppedDir{err: err, stat: dirStat}
+	fw.droppedDirs[dir] = dropped
+
+	if fw.dirRetry != nil {
+		dropped.nextRetry = fw.clock.Now().Add(fw.dirRetry.backoff(0))
+	}
+
+	if errors.Is(err, ErrTooManyDirFile) {
+		fw.metrics.skippedDir(dir)
+	}
+
+	fw.sendError(err)
+}
+
+func (fw *FileWatcher) retryFailed(dir string, dropped *droppedDir, err error) {
+	dropped.err = err
+	dropped.retries++
+
+	if fw.dirRetry.MaxAttempts > 0 && dropped.retries >= fw.dirRetry.MaxAttempts {
+		fw.logger.Warn("abandon dir", "root", dropped.stat.root, "path", dir, "retries", dropped.retries, "err", err)
+
+		dropped.abandoned = true
+
+		fw.sendEvent(&WatchEvent{
+			Name:  dir,
+			Event: DirAbandoned,
+		})
+		fw.sendError(err)
+
+		return
+	}
+
+	fw.logger.Debug("retry dir failed", "root", dropped.stat.root, "path", dir, "retries", dropped.retries, "err", err)
+
+	dropped.nextRetry = fw.clock.Now().Add(fw.dirRetry.backoff(dropped.retries))
+}
+
+// retryDirs retries watching the dropped directories reached their retry time.
+// DirResumed is sent before the events of scanning a resumed directory.
+func (fw *FileWatcher) retryDirs(now, silenceDeadline time.Time) {
+	if fw.dirRetry == nil {
+		return
+	}
+
+	for dir, dropped := range fw.droppedDirs {
+		if dropped.abandoned || now.Before(dropped.nextRetry) {
+			continue
+		}
+
+		dirInfo, err := fw.fs.Stat(dir)
+		if err == nil {
+			_, err = readCheckDir(fw.fs, dir, fw.dirFileCountLimit)
+		}
+
+		if err != nil {
+			if os.IsNotExist(err) {
+				delete(fw.droppedDirs, dir)
+			}
+
+			fw.handleDirError(dir, dropped.stat, err)
+
+			continue
+		}
+
+		fw.logger.Info("resume dir", "root", dropped.stat.root, "path", dir, "retries", dropped.retries)
+
+		delete(fw.droppedDirs, dir)
+
+		fw.sendEvent(&WatchEvent{
+			Name:  dir,
+			Event: DirResumed,
+		})
+
+		// scan all entries of the directory regardless of its mod time.
+		dropped.stat.modTime = time.Time{}
+		fw.newDirs[dir] = dropped.stat
+		fw.checkDirInfo(dir, dirInfo, dropped.stat, silenceDeadline)
+	}
+}
@@ -22,9 +22,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
-
-	"github.com/vogo/vogo/vlog"
 )
 
 var ErrTooManyDirFile = errors.New("too many files under directory")
@@ -36,7 +35,7 @@ func (fw *FileWatcher) checkDirs(silenceDeadline time.Time) {
 }
 
 func (fw *FileWatcher) checkDir(dir string, dirStat *DirStat, silenceDeadline time.Time) {
-	dirInfo, err := os.Stat(dir)
+	dirInfo, err := fw.fs.Stat(dir)
 	if err != nil {
 		fw.handleDirError(dir, dirStat, err)
 
@@ -46,27 +45,35 @@ func (fw *FileWatcher) checkDir(dir string, dirStat *DirStat, silenceDeadline ti
 	fw.checkDirInfo(dir, dirInfo, dirStat, silenceDeadline)
 }
 
-func (fw *FileWatcher) checkDirInfo(dir string, dirInfo os.FileInfo, dirStat *DirStat, silenceDeadline time.Time) {
+// checkDirInfo scans a directory if it's updated, and returns the count of the added files and directories.
+func (fw *FileWatcher) checkDirInfo(dir string, dirInfo os.FileInfo, dirStat *DirStat, silenceDeadline time.Time) int {
 	// dir mod time is updated only when creating or removing sub files.
 	// not need to check files in directory if dir mod time not updated.
 	if !dirInfo.ModTime().After(dirStat.modTime) {
-		vlog.Tracef("ignore not updated dir: %s", dirInfo.Name())
+		fw.trace("ignore not updated dir", "root", dirStat.root, "path", dir)
 
-		return
+		return 0
 	}
 
 	dirStat.modTime = dirInfo.ModTime()
 
-	vlog.Debugf("start check dir: %s", dir)
-	defer vlog.Debugf("end check dir: %s", dir)
+	fw.logger.Debug("start check dir", "root", dirStat.root, "path", dir)
+	defer fw.logger.Debug("end check dir", "root", dirStat.root, "path", dir)
 
-	entries, err := readCheckDir(dir, fw.dirFileCountLimit)
+	entries, err := readCheckDir(fw.fs, dir, fw.dirFileCountLimit)
 	if err != nil {
 		fw.handleDirError(dir, dirStat, err)
 
-		return
+		return 0
 	}
 
+	return fw.scanDirEntries(dir, entries, dirStat, silenceDeadline)
+}
+
+// scanDirEntries adds the new files and sub directories among the entries of a directory,
+// and returns the count of the added, including the ones under the added sub directories.
+func (fw *FileWatcher) scanDirEntries(dir string, entries []os.DirEntry, dirStat *DirStat, silenceDeadline time.Time) int {
+	added := 0
 	subDirMap := make(map[string]os.FileInfo)
 
 	for _, entry := range entries {
@@ -74,14 +81,14 @@ func (fw *FileWatcher) checkDirInfo(dir string, dirInfo os.FileInfo, dirStat *Di
 
 		fileInfo, infoErr := entry.Info()
 		if infoErr != nil {
-			vlog.Debugf("read file info error: %v", infoErr)
+			fw.logger.Debug("read file info error", "root", dirStat.root, "path", filePath, "err", infoErr)
 
 			continue
 		}
 
-		filePath, isDirPath, fileInfo, pathErr := unlink(filePath, fileInfo)
+		filePath, isDirPath, fileInfo, pathErr := unlink(fw.fs, filePath, fileInfo)
 		if pathErr != nil {
-			vlog.Debugf("read file error: %v", pathErr)
+			fw.logger.Debug("read file error", "root", dirStat.root, "path", filePath, "err", pathErr)
 
 			continue
 		}
@@ -92,23 +99,31 @@ func (fw *FileWatcher) checkDirInfo(dir string, dirInfo os.FileInfo, dirStat *Di
 			continue
 		}
 
+		if fw.skipSpecial(filePath, fileInfo, dirStat) {
+			continue
+		}
+
 		if !dirStat.matcher(fileInfo.Name()) {
-			vlog.Tracef("ignore file for not match: %s", fileInfo.Name())
+			fw.trace("ignore file for not match", "root", dirStat.root, "path", filePath)
 
 			continue
 		}
 
-		fw.tryAddNewFile(filePath, fileInfo, silenceDeadline)
+		if fw.tryAddNewFile(filePath, fileInfo, dirStat, silenceDeadline) {
+			added++
+		}
 	}
 
 	// check sub dir
 	for path, fileInfo := range subDirMap {
-		fw.tryAddNewSubDir(fileInfo, path, dirStat, silenceDeadline)
+		added += fw.tryAddNewSubDir(fileInfo, path, dirStat, silenceDeadline)
 	}
+
+	return added
 }
 
-func readCheckDir(dir string, dirFileCountLimit int) ([]os.DirEntry, error) {
-	entries, err := os.ReadDir(dir)
+func readCheckDir(fsys FS, dir string, dirFileCountLimit int) ([]os.DirEntry, error) {
+	entries, err := fsys.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -120,14 +135,79 @@ func readCheckDir(dir string, dirFileCountLimit int) ([]os.DirEntry, error) {
 	return entries, nil
 }
 
-func (fw *FileWatcher) handleDirError(dir string, _ *DirStat, err error) {
-	vlog.Debugf("ignore dir %s for %v", dir, err)
-
-	delete(fw.dirs, dir)
+func (fw *FileWatcher) handleDirError(dir string, dirStat *DirStat, err error) {
+	fw.logger.Debug("ignore dir", "root", dirStat.root, "path", dir, "err", err)
 
 	if os.IsNotExist(err) {
+		fw.removeDir(dir)
+
 		return
 	}
 
-	fw.sendError(err)
+	fw.dropDirForError(dir, dirStat, err)
+}
+
+// removeDir stops watching a removed directory and the sub directories under it.
+// Remove is sent for the files under it, then DirRemove for the directories, the deepest first.
+// A removed root is watched again once it's recreated if persistent roots enabled.
+func (fw *FileWatcher) removeDir(dir string) {
+	if dirStat, ok := fw.watchedDir(dir); ok {
+		fw.tryPendRoot(dir, dirStat)
+	}
+
+	files, dirs := fw.subtree(dir)
+
+	for _, f := range files {
+		fw.removeFile(f, nil)
+	}
+
+	for _, d := range dirs {
+		fw.dropDir(d)
+
+		fw.sendEvent(&WatchEvent{
+			Name:  d,
+			Event: DirRemove,
+		})
+	}
+
+	fw.reanchorPendingRoots(dir)
+}
+
+// dropDir stops watching a directory, but not the files in it.
+func (fw *FileWatcher) dropDir(dir string) {
+	delete(fw.dirs, dir)
+	delete(fw.newDirs, dir)
+	delete(fw.skews, dir)
+	delete(fw.watches, dir)
+
+	// keep watching an anchor of pending roots.
+	if fw.anchors[dir] == 0 {
+		_ = fw.backend.Remove(dir)
+	}
+}
+
+// subtree returns the watched files and directories under dir sorted by path,
+// the directories are sorted the deepest first.
+func (fw *FileWatcher) subtree(dir string) (files, dirs []string) {
+	for _, m := range []map[string]*FileStat{fw.files, fw.newFiles} {
+		for f := range m {
+			if isSubPath(dir, f) {
+				files = append(files, f)
+			}
+		}
+	}
+
+	for _, m := range []map[string]*DirStat{fw.dirs, fw.newDirs} {
+		for d := range m {
+			if isSubPath(dir, d) {
+				dirs = append(dirs, d)
+			}
+		}
+	}
+
+	slices.Sort(files)
+	slices.Sort(dirs)
+	slices.Reverse(dirs)
+
+	return files, dirs
 }
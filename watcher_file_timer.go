@@ -22,14 +22,14 @@ import (
 	"time"
 )
 
-func (fw *FileWatcher) checkFiles(inactiveDeadline, silenceDeadline time.Time) {
+func (fw *FileWatcher) checkFiles(now, inactiveDeadline, silenceDeadline time.Time) {
 	for f, stat := range fw.files {
-		fw.checkFile(f, stat, inactiveDeadline, silenceDeadline)
+		fw.checkFile(f, stat, now, fw.skewDeadline(stat.root, inactiveDeadline), fw.skewDeadline(stat.root, silenceDeadline))
 	}
 }
 
-func (fw *FileWatcher) checkFile(filePath string, stat *FileStat, inactiveDeadline, silenceDeadline time.Time) {
-	info, err := os.Stat(filePath)
+func (fw *FileWatcher) checkFile(filePath string, stat *FileStat, now, inactiveDeadline, silenceDeadline time.Time) {
+	info, err := fw.fs.Stat(filePath)
 	if err != nil {
 		delete(fw.files, filePath)
 
@@ -51,36 +51,140 @@ func (fw *FileWatcher) checkFile(filePath string, stat *FileStat, inactiveDeadli
 		return
 	}
 
+	attrChanged := fw.checkAttr(filePath, info, stat)
+
 	if stat.active {
+		if fw.modifyEvents && (info.ModTime().After(stat.modTime) || stat.modifyPending) {
+			fw.tryNotifyModify(filePath, stat, now)
+		}
+
 		if info.ModTime().Before(inactiveDeadline) {
 			stat.active = false
+			stat.modifyPending = false
+
+			if fw.checksum {
+				fw.updateChecksum(filePath, stat)
+			}
+
 			fw.sendEvent(&WatchEvent{
 				Name:  filePath,
 				Event: Inactive,
 			})
 		}
 	} else {
-		if info.ModTime().After(stat.modTime) {
+		if change := fw.fileChange(filePath, info, stat, attrChanged); change != 0 {
 			stat.active = true
 			fw.sendEvent(&WatchEvent{
-				Name:  filePath,
-				Event: Write,
+				Name:   filePath,
+				Event:  Active,
+				Change: change,
 			})
 		} else if info.ModTime().Before(silenceDeadline) {
-			fw.removeFile(filePath, stat)
+			fw.silenceFile(filePath, stat)
 
 			return
 		}
 	}
 
 	stat.modTime = info.ModTime()
+	stat.fingerprint = fingerprintOf(info)
+}
+
+// silenceFile stops watching a file not updated in the silence duration.
+func (fw *FileWatcher) silenceFile(f string, _ *FileStat) {
+	delete(fw.files, f)
+
+	fw.sendEvent(&WatchEvent{
+		Name:  f,
+		Event: Silence,
+	})
 }
 
 func (fw *FileWatcher) removeFile(f string, _ *FileStat) {
 	delete(fw.files, f)
+	delete(fw.newFiles, f)
 
 	fw.sendEvent(&WatchEvent{
 		Name:  f,
 		Event: Remove,
 	})
 }
+
+// fileChange returns the changed fields of an inactive file, 0 if not updated.
+// The content hash decides if checksum enabled, otherwise the mod time and the fingerprint if enabled.
+// A ctime change caused by an attributes change is not an update.
+func (fw *FileWatcher) fileChange(filePath string, info os.FileInfo, stat *FileStat, attrChanged bool) Change {
+	var change Change
+
+	if info.ModTime().After(stat.modTime) {
+		change |= ChangeModTime
+	}
+
+	if fw.fingerprint {
+		fpChange := stat.fingerprint.diff(fingerprintOf(info))
+		if attrChanged {
+			fpChange &^= ChangeCtime
+		}
+
+		change |= fpChange
+	}
+
+	if fw.checksum {
+		// not hash a file not touched since the last check,
+		// the ctime changes with a write even if the mod time is restored.
+		if info.ModTime().Equal(stat.modTime) && stat.fingerprint.diff(fingerprintOf(info))&(ChangeSize|ChangeCtime) == 0 {
+			return 0
+		}
+
+		if changed, ok := fw.updateChecksum(filePath, stat); ok {
+			if !changed {
+				return 0
+			}
+
+			change |= ChangeContent
+		}
+	}
+
+	return change
+}
+
+// tryNotifyModify sends a Modify event of an active file, at most once per modify interval.
+// A write within the interval is notified by the first check after the interval.
+func (fw *FileWatcher) tryNotifyModify(filePath string, stat *FileStat, now time.Time) {
+	if now.Sub(stat.lastModify) < fw.modifyInterval {
+		stat.modifyPending = true
+
+		return
+	}
+
+	stat.lastModify = now
+	stat.modifyPending = false
+
+	fw.sendEvent(&WatchEvent{
+		Name:   filePath,
+		Event:  Modify,
+		Change: ChangeModTime,
+	})
+}
+
+// checkAttr stores the mode and owner of a file, and returns whether they changed.
+// An Attrib event is sent for the change if enabled.
+func (fw *FileWatcher) checkAttr(filePath string, info os.FileInfo, stat *FileStat) bool {
+	attr := fileAttrOf(info)
+	if attr == stat.attr {
+		return false
+	}
+
+	old := stat.attr
+	stat.attr = attr
+
+	if fw.attribEvents {
+		fw.sendEvent(&WatchEvent{
+			Name:  filePath,
+			Event: Attrib,
+			Attr:  &AttrChange{Old: old, New: attr},
+		})
+	}
+
+	return true
+}
@@ -0,0 +1,257 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pendingRoot a root directory not existing, watched once it's created.
+type pendingRoot struct {
+	includeSub bool
+	matcher    FileMatcher
+
+	// whether the directory is scanned as it was watched when created, false for a removed root.
+	initialScan bool
+
+	// the nearest existing ancestor watched by the backend to attach the root once it's created,
+	// empty for polling backends, or if failed to watch, then the root is polled on every check.
+	anchor string
+}
+
+// watchRoot watches a root directory and scans the files and sub directories in it,
+// which are not notified as new if initialScan is true.
+func (fw *FileWatcher) watchRoot(dir string, dirInfo os.FileInfo, includeSub bool, matcher FileMatcher, initialScan bool) {
+	dirStat := &DirStat{
+		modTime:    dirInfo.ModTime().Add(-time.Second),
+		includeSub: includeSub,
+		matcher:    matcher,
+		root:       dir,
+	}
+
+	if stat, ok := sysStatOf(dirInfo); ok {
+		dirStat.dev = stat.Dev
+		dirStat.hasDev = true
+	}
+
+	fw.dirs[dir] = dirStat
+	delete(fw.droppedDirs, dir)
+
+	if root, ok := fw.pendingRoots[dir]; ok {
+		fw.releaseAnchor(root)
+		delete(fw.pendingRoots, dir)
+	}
+
+	// measure the clock skew before scanning the files.
+	fw.probeSkew(dir)
+
+	fw.initialScan = initialScan
+	fw.checkDirInfo(dir, dirInfo, dirStat, fw.clock.Now().Add(-fw.silenceDuration))
+	fw.initialScan = false
+
+	fw.backendAdd(dir)
+}
+
+// tryPendRoot keeps a removed root directory to watch it again once it's recreated, if persistent roots enabled.
+// It's anchored by reanchorPendingRoots after the removed directories are dropped.
+func (fw *FileWatcher) tryPendRoot(dir string, dirStat *DirStat) {
+	if !fw.persistentRoots || dirStat.root != dir {
+		return
+	}
+
+	fw.logger.Info("wait root to be recreated", "root", dir)
+
+	fw.pendingRoots[dir] = &pendingRoot{
+		includeSub: dirStat.includeSub,
+		matcher:    dirStat.matcher,
+	}
+}
+
+// checkPendingRoots polls the pending root directories not anchored, and watches the created ones.
+func (fw *FileWatcher) checkPendingRoots() {
+	for dir, root := range fw.pendingRoots {
+		if root.anchor != "" {
+			continue
+		}
+
+		dirInfo, err := fw.fs.Stat(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				fw.logger.Debug("stat pending root error", "root", dir, "err", err)
+			}
+
+			continue
+		}
+
+		if !dirInfo.IsDir() {
+			continue
+		}
+
+		fw.attachRoot(dir, dirInfo, root)
+	}
+}
+
+// attachRoot watches a created pending root.
+// The files and sub directories in a recreated root are notified as new after a RootRestored event.
+func (fw *FileWatcher) attachRoot(dir string, dirInfo os.FileInfo, root *pendingRoot) {
+	fw.logger.Info("root created", "root", dir)
+
+	if !root.initialScan {
+		fw.sendEvent(&WatchEvent{
+			Name:  dir,
+			Event: RootRestored,
+		})
+	}
+
+	fw.watchRoot(dir, dirInfo, root.includeSub, root.matcher, root.initialScan)
+}
+
+// anchorPendingRoot watches the nearest existing ancestor of a pending root with the backend,
+// and moves down the anchor for the created directories on the way, until the root is created and attached.
+func (fw *FileWatcher) anchorPendingRoot(dir string, root *pendingRoot) {
+	if fw.backend.Polling() {
+		return
+	}
+
+	for {
+		if dirInfo, err := fw.fs.Stat(dir); err == nil && dirInfo.IsDir() {
+			fw.attachRoot(dir, dirInfo, root)
+
+			return
+		}
+
+		anchor := fw.existingAncestor(dir)
+		if anchor == "" || anchor == root.anchor {
+			return
+		}
+
+		fw.releaseAnchor(root)
+
+		if err := fw.holdAnchor(anchor); err != nil {
+			fw.logger.Debug("watch ancestor of pending root error", "root", dir, "path", anchor, "err", err)
+
+			return
+		}
+
+		root.anchor = anchor
+
+		// check again for the directories created before the anchor is watched.
+	}
+}
+
+// existingAncestor returns the nearest existing ancestor directory of dir, empty if none.
+func (fw *FileWatcher) existingAncestor(dir string) string {
+	for p := filepath.Dir(dir); ; p = filepath.Dir(p) {
+		if info, err := fw.fs.Stat(p); err == nil && info.IsDir() {
+			return p
+		}
+
+		if filepath.Dir(p) == p {
+			return ""
+		}
+	}
+}
+
+// holdAnchor watches an anchor directory with the backend, unless it's watched already.
+func (fw *FileWatcher) holdAnchor(anchor string) error {
+	if fw.anchors[anchor] == 0 {
+		if _, watched := fw.watchedDir(anchor); !watched {
+			if err := fw.backend.Add(anchor); err != nil {
+				return err
+			}
+		}
+	}
+
+	fw.anchors[anchor]++
+
+	return nil
+}
+
+// releaseAnchor stops watching the anchor of a pending root, if it's not used by others nor watched.
+func (fw *FileWatcher) releaseAnchor(root *pendingRoot) {
+	anchor := root.anchor
+	if anchor == "" {
+		return
+	}
+
+	root.anchor = ""
+
+	if fw.anchors[anchor]--; fw.anchors[anchor] > 0 {
+		return
+	}
+
+	delete(fw.anchors, anchor)
+
+	if _, watched := fw.watchedDir(anchor); !watched {
+		_ = fw.backend.Remove(anchor)
+	}
+}
+
+// reanchorPendingRoots anchors the pending roots not anchored yet,
+// and moves up the anchors under a removed directory.
+func (fw *FileWatcher) reanchorPendingRoots(removed string) {
+	for dir, root := range fw.pendingRoots {
+		if root.anchor == "" || isSubPath(removed, root.anchor) {
+			fw.anchorPendingRoot(dir, root)
+		}
+	}
+}
+
+// handlePendingRootEvent attaches or moves down the anchor of the pending roots for a path created on the way,
+// and moves up the anchor removed. It returns true if the event is of an anchor, not of a watched directory.
+func (fw *FileWatcher) handlePendingRootEvent(event BackendEvent) bool {
+	parent := filepath.Dir(event.Name)
+	anchored := fw.anchors[parent] > 0 || fw.anchors[event.Name] > 0
+
+	if !anchored {
+		return false
+	}
+
+	for dir, root := range fw.pendingRoots {
+		if root.anchor != "" && (isSubPath(event.Name, dir) || event.Name == root.anchor) {
+			fw.anchorPendingRoot(dir, root)
+		}
+	}
+
+	_, watched := fw.dirs[parent]
+
+	return !watched
+}
+
+// crossDevice whether a sub directory is on another device than its root, and not to watch it.
+func (fw *FileWatcher) crossDevice(dir string, info os.FileInfo, parentDirStat *DirStat) bool {
+	if !fw.noCrossDevice || !parentDirStat.hasDev {
+		return false
+	}
+
+	if stat, ok := sysStatOf(info); !ok || stat.Dev == parentDirStat.dev {
+		delete(fw.skippedMounts, dir)
+
+		return false
+	}
+
+	if _, ok := fw.skippedMounts[dir]; !ok {
+		fw.logger.Debug("skip dir on another device", "root", parentDirStat.root, "path", dir)
+	}
+
+	fw.skippedMounts[dir] = parentDirStat.root
+
+	return true
+}
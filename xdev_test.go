@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fwatch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vogo/fwatch"
+	"github.com/vogo/fwatch/fwatchtest"
+)
+
+func TestNoCrossDevice(t *testing.T) {
+	t.Parallel()
+
+	fwatchtest.RunMethods(t, func(t *testing.T, h *fwatchtest.Harness) {
+		h.MkdirAll("/logs/app")
+		h.MkdirAll("/logs/archive/old")
+
+		if err := h.FS.Mount("/logs/archive", 2); err != nil {
+			t.Fatal(err)
+		}
+
+		h.Watch("/logs", true, func(string) bool { return true })
+		h.Advance(time.Second + time.Second/2)
+
+		h.WriteFile("/logs/app/a.log", []byte("a"))
+		h.WriteFile("/logs/archive/b.log", []byte("b"))
+		h.WriteFile("/logs/archive/old/c.log", []byte("c"))
+		h.Advance(time.Second)
+		h.ExpectEvents(fwatchtest.Event(fwatch.Create, "/logs/app/a.log"))
+
+		if stats := h.Watcher.Stats(); stats.Dirs != 2 {
+			t.Errorf("want 2 dirs on the root device, got %d", stats.Dirs)
+		}
+
+		e := h.Watcher.Explain("/logs/archive/old/c.log")
+		if e.Reason != fwatch.ReasonCrossDevice || e.Dir != "/logs/archive" || e.Root != "/logs" {
+			t.Errorf("unexpected explanation: %s", e)
+		}
+	}, fwatch.WithNoCrossDevice(true))
+}